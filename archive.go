@@ -0,0 +1,124 @@
+package vsdbg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+	"github.com/paketo-buildpacks/packit/v2/vacation"
+)
+
+//go:generate faux --interface BindingResolver --output fakes/binding_resolver.go
+
+// BindingResolver defines the interface for looking up the service bindings
+// provided by the platform.
+type BindingResolver interface {
+	Resolve(typ, provider, platformDir string) ([]servicebindings.Binding, error)
+}
+
+// localArchive is a debugger archive supplied by the user, like the archive
+// that the -e option of GetVsDbg.sh installs, to be installed instead of a
+// dependency from buildpack.toml.
+type localArchive struct {
+	Path     string
+	Checksum string
+
+	// Source is where the archive was configured: the BP_VSDBG_ARCHIVE
+	// variable or the name of a service binding.
+	Source string
+}
+
+// findLocalArchive returns the archive configured by BP_VSDBG_ARCHIVE, a path
+// in the application source, or else by a service binding of type vsdbg, and
+// reports whether there is one. An archive must always come with the checksum
+// that it is verified against.
+func findLocalArchive(workingDir, platformPath string, bindingResolver BindingResolver) (localArchive, bool, error) {
+	if path, ok := os.LookupEnv(ArchiveEnvVar); ok {
+		checksum, ok := os.LookupEnv(ArchiveChecksumEnvVar)
+		if !ok || strings.TrimSpace(checksum) == "" {
+			return localArchive{}, false, fmt.Errorf("%s must be set when %s is set", ArchiveChecksumEnvVar, ArchiveEnvVar)
+		}
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workingDir, path)
+		}
+
+		return newLocalArchive(path, checksum, ArchiveEnvVar)
+	}
+
+	bindings, err := bindingResolver.Resolve(ArchiveBindingType, "", platformPath)
+	if err != nil {
+		return localArchive{}, false, fmt.Errorf("failed to resolve %s binding: %w", ArchiveBindingType, err)
+	}
+
+	if len(bindings) == 0 {
+		return localArchive{}, false, nil
+	}
+
+	if len(bindings) > 1 {
+		return localArchive{}, false, fmt.Errorf("found %d bindings of type %s: expected at most one", len(bindings), ArchiveBindingType)
+	}
+
+	binding := bindings[0]
+	if _, ok := binding.Entries["archive"]; !ok {
+		return localArchive{}, false, fmt.Errorf("binding %s of type %s is missing the archive entry", binding.Name, ArchiveBindingType)
+	}
+
+	checksumEntry, ok := binding.Entries["checksum"]
+	if !ok {
+		return localArchive{}, false, fmt.Errorf("binding %s of type %s is missing the checksum entry", binding.Name, ArchiveBindingType)
+	}
+
+	checksum, err := checksumEntry.ReadString()
+	if err != nil {
+		return localArchive{}, false, fmt.Errorf("failed to read checksum of binding %s: %w", binding.Name, err)
+	}
+
+	return newLocalArchive(filepath.Join(binding.Path, "archive"), checksum, fmt.Sprintf("binding %s", binding.Name))
+}
+
+func newLocalArchive(path, checksum, source string) (localArchive, bool, error) {
+	if _, err := os.Stat(path); err != nil {
+		return localArchive{}, false, fmt.Errorf("failed to find archive configured by %s: %w", source, err)
+	}
+
+	sum := cargo.Checksum(strings.TrimSpace(checksum))
+
+	return localArchive{
+		Path:     path,
+		Checksum: fmt.Sprintf("%s:%s", sum.Algorithm(), sum.Hash()),
+		Source:   source,
+	}, true, nil
+}
+
+// install verifies the archive against its checksum while extracting it into
+// the layer, as postal does for the dependencies in buildpack.toml.
+func (a localArchive) install(layerPath string) error {
+	file, err := os.Open(a.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	validatedReader := cargo.NewValidatedReader(file, a.Checksum)
+
+	err = vacation.NewArchive(validatedReader).WithName(filepath.Base(a.Path)).Decompress(layerPath)
+	if err != nil {
+		return fmt.Errorf("failed to extract archive: %w", err)
+	}
+
+	ok, err := validatedReader.Valid()
+	if err != nil {
+		return fmt.Errorf("failed to validate archive: %w", err)
+	}
+
+	if !ok {
+		return errors.New("failed to validate archive: checksum does not match")
+	}
+
+	return nil
+}
@@ -0,0 +1,402 @@
+package vsdbg
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// elfMachines maps the architectures of buildpack targets onto the ELF
+// machine that binaries for the architecture are built for.
+var elfMachines = map[string]elf.Machine{
+	"amd64": elf.EM_X86_64,
+	"arm64": elf.EM_AARCH64,
+	"arm":   elf.EM_ARM,
+}
+
+// systemLibraries are the name prefixes of the C and C++ runtime libraries,
+// which the debugger expects the stack to provide rather than bundling them.
+var systemLibraries = []string{
+	"ld-linux",
+	"ld-musl",
+	"libc.",
+	"libc.musl",
+	"libdl.",
+	"libgcc_s.",
+	"libm.",
+	"libpthread.",
+	"librt.",
+	"libstdc++.",
+	"libz.",
+}
+
+// defaultLibcPaths are the patterns of the paths where stacks provide glibc,
+// including the multiarch directories of Debian based distributions.
+var defaultLibcPaths = []string{
+	"/lib/*/libc.so.6",
+	"/usr/lib/*/libc.so.6",
+	"/lib64/libc.so.6",
+	"/usr/lib64/libc.so.6",
+	"/lib/libc.so.6",
+	"/usr/lib/libc.so.6",
+}
+
+// defaultOSReleasePath is the path of the os-release file that identifies the
+// distribution of the build image.
+const defaultOSReleasePath = "/etc/os-release"
+
+// glibcVersionPrefix is the prefix of the symbol versions defined by glibc.
+const glibcVersionPrefix = "GLIBC_"
+
+// BinaryVerifier checks that an installed debugger can run on the build
+// target, so that an archive for the wrong architecture fails the build
+// instead of failing when a debugger is attached.
+type BinaryVerifier struct {
+	libcPaths     []string
+	osReleasePath string
+}
+
+func NewBinaryVerifier() BinaryVerifier {
+	return BinaryVerifier{
+		libcPaths:     defaultLibcPaths,
+		osReleasePath: defaultOSReleasePath,
+	}
+}
+
+// WithLibcPaths returns a verifier that looks for the glibc of the stack at
+// the given path patterns instead of the default ones.
+func (v BinaryVerifier) WithLibcPaths(patterns ...string) BinaryVerifier {
+	v.libcPaths = patterns
+	return v
+}
+
+// WithOSReleasePath returns a verifier that identifies the distribution of
+// the stack from the os-release file at the given path instead of the default
+// one.
+func (v BinaryVerifier) WithOSReleasePath(path string) BinaryVerifier {
+	v.osReleasePath = path
+	return v
+}
+
+// GLIBCReport is the result of comparing the glibc symbol versions needed by
+// an installed debugger with the glibc of the stack.
+type GLIBCReport struct {
+	// Libc is the path of the glibc of the stack, or empty if the stack does
+	// not provide glibc for the target architecture.
+	Libc string
+
+	// Version is the newest symbol version defined by Libc, such as
+	// GLIBC_2.35.
+	Version string
+
+	// Distro and DistroVersion are the ID and VERSION_ID of the build image
+	// that Libc was found in, as read from its os-release file. They are empty
+	// if the file does not exist.
+	Distro        string
+	DistroVersion string
+
+	// Missing are the imported symbols whose version Libc does not define.
+	Missing []MissingSymbolVersion
+}
+
+// MissingSymbolVersion is a symbol that a file of the installed debugger
+// imports with a glibc symbol version that the stack does not provide.
+type MissingSymbolVersion struct {
+	File    string
+	Library string
+	Symbol  string
+	Version string
+}
+
+// Verify checks that the vsdbg file in the given directory is an ELF
+// executable for the given architecture, that the shared libraries it needs
+// beyond the system libraries are bundled alongside it, and that every
+// bundled shared library is built for the same architecture.
+func (v BinaryVerifier) Verify(path, arch string) error {
+	machine, ok := elfMachines[arch]
+	if !ok {
+		return fmt.Errorf("failed to verify vsdbg: unsupported architecture %q", arch)
+	}
+
+	executablePath := filepath.Join(path, "vsdbg")
+	executable, err := openELF(executablePath, machine, arch)
+	if err != nil {
+		return err
+	}
+	defer executable.Close()
+
+	if executable.Type != elf.ET_EXEC && executable.Type != elf.ET_DYN {
+		return fmt.Errorf("failed to verify vsdbg: %s is not an executable: found ELF type %s", executablePath, executable.Type)
+	}
+
+	needed, err := executable.ImportedLibraries()
+	if err != nil {
+		return fmt.Errorf("failed to verify vsdbg: failed to read the shared libraries needed by %s: %w", executablePath, err)
+	}
+
+	libraries, err := bundledLibraries(path)
+	if err != nil {
+		return fmt.Errorf("failed to verify vsdbg: %w", err)
+	}
+
+	for _, library := range needed {
+		if isSystemLibrary(library) {
+			continue
+		}
+
+		libraryPath := filepath.Join(path, library)
+		_, err := os.Stat(libraryPath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to verify vsdbg: %s needs the shared library %s, which is not bundled in %s", executablePath, library, path)
+			}
+
+			return fmt.Errorf("failed to verify vsdbg: %w", err)
+		}
+
+		if !slices.Contains(libraries, libraryPath) {
+			libraries = append(libraries, libraryPath)
+		}
+	}
+
+	for _, libraryPath := range libraries {
+		library, err := openELF(libraryPath, machine, arch)
+		if err != nil {
+			return err
+		}
+		library.Close()
+	}
+
+	return nil
+}
+
+// CheckGLIBC compares the glibc symbol versions imported by the vsdbg file and
+// the bundled shared libraries in the given directory with the versions
+// defined by the glibc of the stack for the given architecture.
+func (v BinaryVerifier) CheckGLIBC(path, arch string) (GLIBCReport, error) {
+	machine, ok := elfMachines[arch]
+	if !ok {
+		return GLIBCReport{}, fmt.Errorf("failed to check glibc compatibility: unsupported architecture %q", arch)
+	}
+
+	libc, provided, err := v.findLibc(machine)
+	if err != nil {
+		return GLIBCReport{}, err
+	}
+
+	if libc == "" {
+		return GLIBCReport{}, nil
+	}
+
+	report := GLIBCReport{
+		Libc:    libc,
+		Version: glibcVersionPrefix + joinVersion(provided),
+	}
+
+	report.Distro, report.DistroVersion, err = readOSRelease(v.osReleasePath)
+	if err != nil {
+		return GLIBCReport{}, fmt.Errorf("failed to check glibc compatibility: %w", err)
+	}
+
+	libraries, err := bundledLibraries(path)
+	if err != nil {
+		return GLIBCReport{}, fmt.Errorf("failed to check glibc compatibility: %w", err)
+	}
+
+	for _, file := range append([]string{filepath.Join(path, "vsdbg")}, libraries...) {
+		missing, err := missingSymbolVersions(file, provided)
+		if err != nil {
+			return GLIBCReport{}, err
+		}
+
+		report.Missing = append(report.Missing, missing...)
+	}
+
+	return report, nil
+}
+
+// findLibc returns the path of the first glibc for the given machine that
+// matches the libc path patterns of the verifier, and the newest glibc symbol
+// version it defines. An empty path is returned if there is no such glibc.
+func (v BinaryVerifier) findLibc(machine elf.Machine) (string, []uint64, error) {
+	for _, pattern := range v.libcPaths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to check glibc compatibility: %w", err)
+		}
+
+		for _, match := range matches {
+			file, err := elf.Open(match)
+			if err != nil {
+				continue
+			}
+
+			if file.Machine != machine {
+				file.Close()
+				continue
+			}
+
+			versions, err := file.DynamicVersions()
+			file.Close()
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to check glibc compatibility: failed to read the symbol versions defined by %s: %w", match, err)
+			}
+
+			var newest []uint64
+			for _, version := range versions {
+				if v, ok := parseGLIBCVersion(version.Name); ok && slices.Compare(v, newest) > 0 {
+					newest = v
+				}
+			}
+
+			if newest == nil {
+				continue
+			}
+
+			return match, newest, nil
+		}
+	}
+
+	return "", nil, nil
+}
+
+// readOSRelease returns the ID and VERSION_ID fields of the os-release file at
+// the given path, or empty values if there is no such file.
+func readOSRelease(path string) (string, string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", "", nil
+		}
+
+		return "", "", err
+	}
+
+	var id, versionID string
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "ID":
+			id = value
+		case "VERSION_ID":
+			versionID = value
+		}
+	}
+
+	return id, versionID, nil
+}
+
+// missingSymbolVersions returns the symbols that the ELF file at the given
+// path imports with a glibc symbol version newer than the provided one.
+func missingSymbolVersions(path string, provided []uint64) ([]MissingSymbolVersion, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check glibc compatibility: %w", err)
+	}
+	defer file.Close()
+
+	symbols, err := file.ImportedSymbols()
+	if err != nil {
+		if errors.Is(err, elf.ErrNoSymbols) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to check glibc compatibility: failed to read the symbols imported by %s: %w", path, err)
+	}
+
+	var missing []MissingSymbolVersion
+	for _, symbol := range symbols {
+		required, ok := parseGLIBCVersion(symbol.Version)
+		if !ok || slices.Compare(required, provided) <= 0 {
+			continue
+		}
+
+		missing = append(missing, MissingSymbolVersion{
+			File:    filepath.Base(path),
+			Library: symbol.Library,
+			Symbol:  symbol.Name,
+			Version: symbol.Version,
+		})
+	}
+
+	return missing, nil
+}
+
+// parseGLIBCVersion returns the numeric components of a glibc symbol version,
+// such as GLIBC_2.2.5, and reports whether the version is one.
+func parseGLIBCVersion(version string) ([]uint64, bool) {
+	number, ok := strings.CutPrefix(version, glibcVersionPrefix)
+	if !ok {
+		return nil, false
+	}
+
+	var components []uint64
+	for _, field := range strings.Split(number, ".") {
+		component, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		components = append(components, component)
+	}
+
+	return components, true
+}
+
+func joinVersion(components []uint64) string {
+	fields := make([]string, len(components))
+	for i, component := range components {
+		fields[i] = strconv.FormatUint(component, 10)
+	}
+
+	return strings.Join(fields, ".")
+}
+
+// bundledLibraries returns the paths of the shared libraries bundled with the
+// debugger in the given directory.
+func bundledLibraries(path string) ([]string, error) {
+	return filepath.Glob(filepath.Join(path, "*.so"))
+}
+
+// openELF opens the ELF file at the given path and checks that it is built
+// for the given machine.
+func openELF(path string, machine elf.Machine, arch string) (*elf.File, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify vsdbg: %s is not an ELF file: %w", path, err)
+	}
+
+	if file.Machine != machine {
+		file.Close()
+		return nil, fmt.Errorf("failed to verify vsdbg: %s is built for %s, but the target architecture is %s", path, machineArch(file.Machine), arch)
+	}
+
+	return file, nil
+}
+
+// machineArch returns the target architecture of the given ELF machine, or
+// the name of the machine if no target architecture matches it.
+func machineArch(machine elf.Machine) string {
+	for arch, m := range elfMachines {
+		if m == machine {
+			return arch
+		}
+	}
+
+	return machine.String()
+}
+
+func isSystemLibrary(library string) bool {
+	return slices.ContainsFunc(systemLibraries, func(prefix string) bool {
+		return strings.HasPrefix(library, prefix)
+	})
+}
@@ -0,0 +1,434 @@
+package vsdbg_test
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vsdbg "github.com/paketo-buildpacks/vsdbg"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBinaryVerifier(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir string
+		libcDir  string
+		verifier vsdbg.BinaryVerifier
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeELF(t, filepath.Join(layerDir, "vsdbg"), elfFixture{
+			Machine: elf.EM_X86_64,
+			Type:    elf.ET_DYN,
+			Needed:  []string{"libc.so.6", "libstdc++.so.6", "libvsdbg.so"},
+			Imports: []elf.ImportedSymbol{
+				{Name: "malloc", Library: "libc.so.6", Version: "GLIBC_2.2.5"},
+				{Name: "pthread_create", Library: "libc.so.6", Version: "GLIBC_2.34"},
+				{Name: "_ZSt9terminatev", Library: "libstdc++.so.6", Version: "GLIBCXX_3.4"},
+			},
+		})
+		writeELF(t, filepath.Join(layerDir, "libvsdbg.so"), elfFixture{
+			Machine: elf.EM_X86_64,
+			Type:    elf.ET_DYN,
+			Imports: []elf.ImportedSymbol{
+				{Name: "log2", Library: "libm.so.6", Version: "GLIBC_2.29"},
+			},
+		})
+		writeELF(t, filepath.Join(layerDir, "libcoreclr.so"), elfFixture{Machine: elf.EM_X86_64, Type: elf.ET_DYN})
+
+		libcDir, err = os.MkdirTemp("", "libc")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(libcDir, "aarch64-linux-gnu"), os.ModePerm)).To(Succeed())
+		writeELF(t, filepath.Join(libcDir, "aarch64-linux-gnu", "libc.so.6"), elfFixture{
+			Machine:  elf.EM_AARCH64,
+			Type:     elf.ET_DYN,
+			Versions: []string{"libc.so.6", "GLIBC_2.17", "GLIBC_2.35", "GLIBC_PRIVATE"},
+		})
+
+		Expect(os.MkdirAll(filepath.Join(libcDir, "x86_64-linux-gnu"), os.ModePerm)).To(Succeed())
+		writeELF(t, filepath.Join(libcDir, "x86_64-linux-gnu", "libc.so.6"), elfFixture{
+			Machine:  elf.EM_X86_64,
+			Type:     elf.ET_DYN,
+			Versions: []string{"libc.so.6", "GLIBC_2.2.5", "GLIBC_2.28", "GLIBC_2.3", "GLIBC_PRIVATE"},
+		})
+
+		Expect(os.WriteFile(filepath.Join(libcDir, "os-release"), []byte(`NAME="Ubuntu"
+VERSION_ID="22.04"
+ID=ubuntu
+ID_LIKE=debian
+`), 0600)).To(Succeed())
+
+		verifier = vsdbg.NewBinaryVerifier().
+			WithLibcPaths(filepath.Join(libcDir, "*", "libc.so.6")).
+			WithOSReleasePath(filepath.Join(libcDir, "os-release"))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(libcDir)).To(Succeed())
+	})
+
+	context("Verify", func() {
+		it("accepts an executable and bundled libraries for the target architecture", func() {
+			Expect(verifier.Verify(layerDir, "amd64")).To(Succeed())
+		})
+
+		context("when the executable is built for another architecture", func() {
+			it("returns an error", func() {
+				err := verifier.Verify(layerDir, "arm64")
+				Expect(err).To(MatchError(ContainSubstring("vsdbg is built for amd64, but the target architecture is arm64")))
+			})
+		})
+
+		context("when a bundled library is built for another architecture", func() {
+			it.Before(func() {
+				writeELF(t, filepath.Join(layerDir, "libcoreclr.so"), elfFixture{Machine: elf.EM_AARCH64, Type: elf.ET_DYN})
+			})
+
+			it("returns an error", func() {
+				err := verifier.Verify(layerDir, "amd64")
+				Expect(err).To(MatchError(ContainSubstring("libcoreclr.so is built for arm64, but the target architecture is amd64")))
+			})
+		})
+
+		context("when a library needed by the executable is not bundled", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(layerDir, "libvsdbg.so"))).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				err := verifier.Verify(layerDir, "amd64")
+				Expect(err).To(MatchError(ContainSubstring("needs the shared library libvsdbg.so, which is not bundled in")))
+			})
+		})
+
+		context("when the executable is not an ELF file", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layerDir, "vsdbg"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				err := verifier.Verify(layerDir, "amd64")
+				Expect(err).To(MatchError(ContainSubstring("vsdbg is not an ELF file")))
+			})
+		})
+
+		context("when the executable is missing", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(layerDir, "vsdbg"))).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				err := verifier.Verify(layerDir, "amd64")
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+		})
+
+		context("when the vsdbg file is not an executable", func() {
+			it.Before(func() {
+				writeELF(t, filepath.Join(layerDir, "vsdbg"), elfFixture{Machine: elf.EM_X86_64, Type: elf.ET_REL})
+			})
+
+			it("returns an error", func() {
+				err := verifier.Verify(layerDir, "amd64")
+				Expect(err).To(MatchError(ContainSubstring("is not an executable: found ELF type ET_REL")))
+			})
+		})
+
+		context("when the architecture is not supported", func() {
+			it("returns an error", func() {
+				err := verifier.Verify(layerDir, "s390x")
+				Expect(err).To(MatchError(`failed to verify vsdbg: unsupported architecture "s390x"`))
+			})
+		})
+	})
+
+	context("CheckGLIBC", func() {
+		it("reports the imported symbols whose glibc version the stack does not provide", func() {
+			report, err := verifier.CheckGLIBC(layerDir, "amd64")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report).To(Equal(vsdbg.GLIBCReport{
+				Libc:          filepath.Join(libcDir, "x86_64-linux-gnu", "libc.so.6"),
+				Version:       "GLIBC_2.28",
+				Distro:        "ubuntu",
+				DistroVersion: "22.04",
+				Missing: []vsdbg.MissingSymbolVersion{
+					{File: "vsdbg", Library: "libc.so.6", Symbol: "pthread_create", Version: "GLIBC_2.34"},
+					{File: "libvsdbg.so", Library: "libm.so.6", Symbol: "log2", Version: "GLIBC_2.29"},
+				},
+			}))
+		})
+
+		context("when the stack provides a newer glibc", func() {
+			it.Before(func() {
+				writeELF(t, filepath.Join(libcDir, "x86_64-linux-gnu", "libc.so.6"), elfFixture{
+					Machine:  elf.EM_X86_64,
+					Type:     elf.ET_DYN,
+					Versions: []string{"GLIBC_2.2.5", "GLIBC_2.34", "GLIBC_2.35"},
+				})
+			})
+
+			it("reports no missing symbol versions", func() {
+				report, err := verifier.CheckGLIBC(layerDir, "amd64")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Version).To(Equal("GLIBC_2.35"))
+				Expect(report.Missing).To(BeEmpty())
+			})
+		})
+
+		context("when the stack has no os-release file", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(libcDir, "os-release"))).To(Succeed())
+			})
+
+			it("reports no distribution", func() {
+				report, err := verifier.CheckGLIBC(layerDir, "amd64")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Version).To(Equal("GLIBC_2.28"))
+				Expect(report.Distro).To(BeEmpty())
+				Expect(report.DistroVersion).To(BeEmpty())
+			})
+		})
+
+		context("when the stack does not provide glibc for the target architecture", func() {
+			it.Before(func() {
+				Expect(os.RemoveAll(filepath.Join(libcDir, "x86_64-linux-gnu"))).To(Succeed())
+			})
+
+			it("returns an empty report", func() {
+				report, err := verifier.CheckGLIBC(layerDir, "amd64")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report).To(Equal(vsdbg.GLIBCReport{}))
+			})
+		})
+
+		context("when the architecture is not supported", func() {
+			it("returns an error", func() {
+				_, err := verifier.CheckGLIBC(layerDir, "s390x")
+				Expect(err).To(MatchError(`failed to check glibc compatibility: unsupported architecture "s390x"`))
+			})
+		})
+
+		context("when the executable is not an ELF file", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layerDir, "vsdbg"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := verifier.CheckGLIBC(layerDir, "amd64")
+				Expect(err).To(MatchError(ContainSubstring("failed to check glibc compatibility")))
+			})
+		})
+	})
+}
+
+// elfFixture describes a minimal 64-bit little endian ELF file.
+type elfFixture struct {
+	Machine elf.Machine
+	Type    elf.Type
+
+	// Needed are the shared libraries listed in the dynamic section.
+	Needed []string
+
+	// Imports are the undefined symbols, with the library and version that
+	// they are imported from.
+	Imports []elf.ImportedSymbol
+
+	// Versions are the symbol versions defined by the file.
+	Versions []string
+}
+
+// writeELF writes the ELF file described by the fixture to the given path.
+func writeELF(t *testing.T, path string, fixture elfFixture) {
+	t.Helper()
+
+	le := binary.LittleEndian
+
+	dynstr := []byte{0}
+	addString := func(value string) uint32 {
+		offset := uint32(len(dynstr))
+		dynstr = append(append(dynstr, value...), 0)
+		return offset
+	}
+
+	var dynamic []byte
+	for _, library := range fixture.Needed {
+		dynamic = le.AppendUint64(dynamic, uint64(elf.DT_NEEDED))
+		dynamic = le.AppendUint64(dynamic, uint64(addString(library)))
+	}
+	dynamic = le.AppendUint64(le.AppendUint64(dynamic, uint64(elf.DT_NULL)), 0)
+
+	// Version indexes 0 and 1 are reserved for local and global symbols.
+	dynsym := make([]byte, binary.Size(elf.Sym64{}))
+	versym := le.AppendUint16(nil, 0)
+	var libraries []string
+	needs := map[string][]string{}
+	versionIndexes := map[string]uint16{}
+	nextIndex := uint16(2)
+	for _, version := range fixture.Versions {
+		versionIndexes["\x00"+version] = nextIndex
+		nextIndex++
+	}
+	for _, symbol := range fixture.Imports {
+		key := symbol.Library + "\x00" + symbol.Version
+		if _, ok := versionIndexes[key]; !ok {
+			if _, ok := needs[symbol.Library]; !ok {
+				libraries = append(libraries, symbol.Library)
+			}
+			needs[symbol.Library] = append(needs[symbol.Library], symbol.Version)
+			versionIndexes[key] = nextIndex
+			nextIndex++
+		}
+
+		dynsym = le.AppendUint32(dynsym, addString(symbol.Name))
+		dynsym = append(dynsym, byte(elf.STB_GLOBAL)<<4|byte(elf.STT_FUNC), 0)
+		dynsym = le.AppendUint16(dynsym, uint16(elf.SHN_UNDEF))
+		dynsym = le.AppendUint64(le.AppendUint64(dynsym, 0), 0)
+		versym = le.AppendUint16(versym, versionIndexes[key])
+	}
+
+	var verneed []byte
+	for i, library := range libraries {
+		next := uint32(16 + 16*len(needs[library]))
+		if i == len(libraries)-1 {
+			next = 0
+		}
+		verneed = le.AppendUint16(verneed, 1)
+		verneed = le.AppendUint16(verneed, uint16(len(needs[library])))
+		verneed = le.AppendUint32(verneed, addString(library))
+		verneed = le.AppendUint32(verneed, 16)
+		verneed = le.AppendUint32(verneed, next)
+
+		for j, version := range needs[library] {
+			next := uint32(16)
+			if j == len(needs[library])-1 {
+				next = 0
+			}
+			verneed = le.AppendUint32(verneed, 0)
+			verneed = le.AppendUint16(verneed, 0)
+			verneed = le.AppendUint16(verneed, versionIndexes[library+"\x00"+version])
+			verneed = le.AppendUint32(verneed, addString(version))
+			verneed = le.AppendUint32(verneed, next)
+		}
+	}
+
+	var verdef []byte
+	for i, version := range fixture.Versions {
+		next := uint32(28)
+		if i == len(fixture.Versions)-1 {
+			next = 0
+		}
+		verdef = le.AppendUint16(verdef, 1)
+		verdef = le.AppendUint16(verdef, 0)
+		verdef = le.AppendUint16(verdef, versionIndexes["\x00"+version])
+		verdef = le.AppendUint16(verdef, 1)
+		verdef = le.AppendUint32(verdef, 0)
+		verdef = le.AppendUint32(verdef, 20)
+		verdef = le.AppendUint32(verdef, next)
+		verdef = le.AppendUint32(verdef, addString(version))
+		verdef = le.AppendUint32(verdef, 0)
+	}
+
+	type section struct {
+		name    string
+		typ     elf.SectionType
+		data    []byte
+		link    uint32
+		entsize uint64
+	}
+	sections := []section{
+		{},
+		{name: ".dynstr", typ: elf.SHT_STRTAB},
+		{name: ".dynamic", typ: elf.SHT_DYNAMIC, data: dynamic, link: 1, entsize: 16},
+		{name: ".dynsym", typ: elf.SHT_DYNSYM, data: dynsym, link: 1, entsize: uint64(binary.Size(elf.Sym64{}))},
+		{name: ".gnu.version", typ: elf.SHT_GNU_VERSYM, data: versym, link: 3, entsize: 2},
+		{name: ".gnu.version_r", typ: elf.SHT_GNU_VERNEED, data: verneed, link: 1},
+		{name: ".gnu.version_d", typ: elf.SHT_GNU_VERDEF, data: verdef, link: 1},
+		{name: ".shstrtab", typ: elf.SHT_STRTAB},
+	}
+
+	shstrtab := []byte{0}
+
+	var body bytes.Buffer
+	body.Write(make([]byte, binary.Size(elf.Header64{})))
+
+	var headers []elf.Section64
+	for i, section := range sections {
+		if i == 0 {
+			headers = append(headers, elf.Section64{})
+			continue
+		}
+
+		name := uint32(len(shstrtab))
+		shstrtab = append(append(shstrtab, section.name...), 0)
+
+		switch section.name {
+		case ".dynstr":
+			section.data = dynstr
+		case ".shstrtab":
+			section.data = shstrtab
+		}
+
+		for body.Len()%8 != 0 {
+			body.WriteByte(0)
+		}
+
+		headers = append(headers, elf.Section64{
+			Name:      name,
+			Type:      uint32(section.typ),
+			Off:       uint64(body.Len()),
+			Size:      uint64(len(section.data)),
+			Link:      section.link,
+			Addralign: 8,
+			Entsize:   section.entsize,
+		})
+		body.Write(section.data)
+	}
+
+	for body.Len()%8 != 0 {
+		body.WriteByte(0)
+	}
+
+	header := elf.Header64{
+		Type:      uint16(fixture.Type),
+		Machine:   uint16(fixture.Machine),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     uint64(body.Len()),
+		Ehsize:    uint16(binary.Size(elf.Header64{})),
+		Shentsize: uint16(binary.Size(elf.Section64{})),
+		Shnum:     uint16(len(headers)),
+		Shstrndx:  uint16(len(headers) - 1),
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	if err := binary.Write(&body, le, headers); err != nil {
+		t.Fatal(err)
+	}
+
+	var headerBytes bytes.Buffer
+	if err := binary.Write(&headerBytes, le, header); err != nil {
+		t.Fatal(err)
+	}
+
+	content := body.Bytes()
+	copy(content, headerBytes.Bytes())
+
+	if err := os.WriteFile(path, content, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,8 +1,13 @@
 package vsdbg
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/paketo-buildpacks/packit/v2"
@@ -16,9 +21,14 @@ import (
 
 //go:generate faux --interface SBOMGenerator --output fakes/sbom_generator.go
 //go:generate faux --interface DependencyManager --output fakes/dependency_manager.go
+//go:generate faux --interface InstallationVerifier --output fakes/installation_verifier.go
 
+// SBOMGenerator defines the interface for generating the SBOM of the layer,
+// by scanning it, from the installed dependency, or from both.
 type SBOMGenerator interface {
 	Generate(dir string) (sbom.SBOM, error)
+	GenerateFromDependency(dependency postal.Dependency, dir string) (sbom.SBOM, error)
+	GenerateWithDependency(dependency postal.Dependency, dir string) (sbom.SBOM, error)
 }
 
 // DependencyManager defines the interface for picking the best matching
@@ -28,8 +38,18 @@ type DependencyManager interface {
 	Deliver(dependency postal.Dependency, cnbPath, destinationPath, platformPath string) error
 }
 
+// InstallationVerifier defines the interface for checking that an installed
+// debugger can run on the target architecture and with the glibc of the
+// stack.
+type InstallationVerifier interface {
+	Verify(path, arch string) error
+	CheckGLIBC(path, arch string) (GLIBCReport, error)
+}
+
 func Build(
 	dependencyManager DependencyManager,
+	bindingResolver BindingResolver,
+	installationVerifier InstallationVerifier,
 	sbomGenerator SBOMGenerator,
 	logger scribe.Emitter,
 	clock chronos.Clock,
@@ -40,19 +60,99 @@ func Build(
 		planner := draft.NewPlanner()
 
 		logger.Process("Resolving Visual Studio Debugger version")
-		entry, sortedEntries := planner.Resolve(PlanDependencyVSDBG, context.Plan.Entries, nil)
+
+		// The entries are copied so that the version set by BP_VSDBG_VERSION is
+		// not written into the plan of the build context. An empty version is
+		// ignored rather than overriding the other version sources.
+		entries := slices.Clone(context.Plan.Entries)
+		if version := strings.TrimSpace(os.Getenv(VersionSourceEnvVar)); version != "" {
+			entries = append(entries, packit.BuildpackPlanEntry{
+				Name: PlanDependencyVSDBG,
+				Metadata: map[string]interface{}{
+					"version":        version,
+					"version-source": VersionSourceEnvVar,
+				},
+			})
+		}
+
+		entry, sortedEntries := planner.Resolve(PlanDependencyVSDBG, entries, []interface{}{
+			VersionSourceEnvVar,
+			regexp.MustCompile(`.*\.(cs|fs|vb)proj$`),
+			BuildpackYMLSource,
+		})
 		logger.Candidates(sortedEntries)
 
-		version, _ := entry.Metadata["version"].(string)
-		dependency, err := dependencyManager.Resolve(filepath.Join(context.CNBPath, "buildpack.toml"), entry.Name, version, context.Stack)
+		buildpackTOMLPath := filepath.Join(context.CNBPath, "buildpack.toml")
+		config, err := parseBuildpackConfig(buildpackTOMLPath)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
-		logger.SelectedDependency(entry, dependency, clock.Now())
+		deprecation, err := parseDeprecationPolicy()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		glibcCheck, err := parseGLIBCCheck()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		cacheValidation, err := parseCacheValidation()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		sbomStrategy, err := parseSBOMStrategy()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		sourceDate, err := parseSourceDateEpoch()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		trim, err := parseTrimProfile()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		archive, useArchive, err := findLocalArchive(context.WorkingDir, context.Platform.Path, bindingResolver)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		var dependency postal.Dependency
+		if useArchive {
+			if version, _ := entry.Metadata["version"].(string); version != "" {
+				versionSource, _ := entry.Metadata["version-source"].(string)
+				logger.Subprocess("WARNING: The version %q requested by %s is ignored, as the archive configured by %s is installed", version, versionSource, archive.Source)
+			}
+
+			logger.Subprocess("Selected %s archive (using %s): %s", PlanDependencyVSDBG, archive.Source, archive.Path)
+			logger.Break()
+
+			// The source records where the archive was configured rather than
+			// its path, which only exists on the build host.
+			dependency = postal.Dependency{
+				ID:       PlanDependencyVSDBG,
+				Name:     "Visual Studio Debugger",
+				Checksum: archive.Checksum,
+				Source:   archive.Source,
+			}
+		} else {
+			dependency, err = resolveDependency(dependencyManager, config, buildpackTOMLPath, entry, context, deprecation, logger, clock.Now())
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
 
 		launch, build := planner.MergeLayerTypes(PlanDependencyVSDBG, context.Plan.Entries)
 
+		target := newBuildTarget(context.TargetInfo, context.TargetDistro)
+		debugger := newInstalledDebugger(dependency, target)
+
 		layer, err := context.Layers.Get(PlanDependencyVSDBG)
 		if err != nil {
 			return packit.BuildResult{}, err
@@ -60,12 +160,35 @@ func Build(
 
 		cachedChecksum, ok := layer.Metadata["dependency-checksum"].(string)
 		if ok && cargo.Checksum(dependency.Checksum).MatchString(cachedChecksum) {
-			logger.Process("Reusing cached layer %s", layer.Path)
-			layer.Launch, layer.Build, layer.Cache = launch, build, build
+			// A layer that was partly restored or edited since it was cached is
+			// installed again rather than reused.
+			err = errors.New("the layer has no manifest")
+			if manifest, ok := parseLayerManifest(layer.Metadata["manifest"]); ok {
+				err = manifest.verify(layer.Path, cacheValidation)
+			}
+
+			// A layer that was trimmed differently is missing files that are
+			// now requested, or holds files that are now removed.
+			if cachedTrim, _ := layer.Metadata["trim"].(string); err == nil && cachedTrim != trim.String() {
+				err = fmt.Errorf("the trim profile changed from %q to %q", cachedTrim, trim.String())
+			}
+
+			if err == nil {
+				logger.Process("Reusing cached layer %s", layer.Path)
+				layer.Launch, layer.Build, layer.Cache = launch, build, build
 
-			return packit.BuildResult{
-				Layers: []packit.Layer{layer},
-			}, nil
+				// Layers cached before the debugger was described in its
+				// environment are given the variables as well.
+				debugger.configureEnvironment(layer)
+
+				return packit.BuildResult{
+					Layers: []packit.Layer{layer},
+					Launch: debugger.launchMetadata(logger, launch),
+				}, nil
+			}
+
+			logger.Process("Reinstalling cached layer %s: %s", layer.Path, err)
+			logger.Break()
 		}
 
 		layer, err = layer.Reset()
@@ -76,9 +199,17 @@ func Build(
 		layer.Launch, layer.Build, layer.Cache = launch, build, build
 
 		logger.Process("Executing build process")
-		logger.Subprocess("Installing Visual Studio Debugger %s", dependency.Version)
+		if useArchive {
+			logger.Subprocess("Installing Visual Studio Debugger from %s", archive.Path)
+		} else {
+			logger.Subprocess("Installing Visual Studio Debugger %s", dependency.Version)
+		}
 
 		duration, err := clock.Measure(func() error {
+			if useArchive {
+				return archive.install(layer.Path)
+			}
+
 			return dependencyManager.Deliver(dependency, context.CNBPath, layer.Path, context.Platform.Path)
 		})
 		if err != nil {
@@ -88,6 +219,34 @@ func Build(
 		logger.Action("Completed in %s", duration.Round(time.Millisecond))
 		logger.Break()
 
+		if profile := trim.String(); profile != "" {
+			logger.Subprocess("Trimming the layer: %s", profile)
+			result, err := trim.trim(layer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			logger.Action("Removed %d files (%.1f MB)", result.Files, float64(result.Bytes)/(1<<20))
+			logger.Break()
+		}
+
+		err = installationVerifier.Verify(layer.Path, target.Arch)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if glibcCheck != glibcCheckSkip && !target.isMusl() {
+			report, err := installationVerifier.CheckGLIBC(layer.Path, target.Arch)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			err = glibcCheck.apply(logger, report, target)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
 		vsdbgBinPath := filepath.Join(layer.Path, "vsdbg")
 		info, err := os.Stat(vsdbgBinPath)
 		if err != nil {
@@ -100,10 +259,18 @@ func Build(
 			return packit.BuildResult{}, err
 		}
 
+		logger.Subprocess("Normalizing file times to %s, ownership and permissions", sourceDate.Format(time.RFC3339))
+		err = normalizeLayer(layer.Path, sourceDate)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+		logger.Break()
+
 		logger.GeneratingSBOM(layer.Path)
+		logger.Subprocess("Using the %s SBOM strategy", sbomStrategy)
 		var sbomContent sbom.SBOM
 		duration, err = clock.Measure(func() error {
-			sbomContent, err = sbomGenerator.Generate(layer.Path)
+			sbomContent, err = sbomStrategy.generate(sbomGenerator, dependency, layer.Path)
 			return err
 		})
 		if err != nil {
@@ -119,15 +286,108 @@ func Build(
 			return packit.BuildResult{}, err
 		}
 
+		manifest, err := newLayerManifest(layer.Path)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
 		layer.SharedEnv.Append("PATH", layer.Path, ":")
+		debugger.configureEnvironment(layer)
 		logger.EnvironmentVariables(layer)
 
 		layer.Metadata = map[string]interface{}{
 			"dependency-checksum": dependency.Checksum,
+			"manifest":            manifest.metadata(),
+		}
+		if useArchive {
+			layer.Metadata["archive-source"] = archive.Source
+		}
+		if profile := trim.String(); profile != "" {
+			layer.Metadata["trim"] = profile
 		}
 
 		return packit.BuildResult{
 			Layers: []packit.Layer{layer},
+			Launch: debugger.launchMetadata(logger, launch),
 		}, nil
 	}
 }
+
+// resolveDependency selects the dependency from buildpack.toml that satisfies
+// the version requested by the build plan entry on the build target, and logs
+// the selection.
+func resolveDependency(
+	dependencyManager DependencyManager,
+	config buildpackConfig,
+	buildpackTOMLPath string,
+	entry packit.BuildpackPlanEntry,
+	context packit.BuildContext,
+	deprecation deprecationPolicy,
+	logger scribe.Emitter,
+	now time.Time,
+) (postal.Dependency, error) {
+	requestedVersion, _ := entry.Metadata["version"].(string)
+	versionSource, _ := entry.Metadata["version-source"].(string)
+
+	version := requestedVersion
+	if constraint, ok := config.resolveVersionAlias(version); ok {
+		logger.Subprocess("Resolved meta version %q to %q", version, constraint)
+		version = constraint
+	}
+
+	// A fully qualified upstream version, w.x.y.z, is not a valid semantic
+	// version constraint, so it is requested in its w.x.y+z form.
+	if v, ok := parseDebuggerVersion(version); ok && v.hasRevision {
+		version = v.String()
+	}
+
+	target := newBuildTarget(context.TargetInfo, context.TargetDistro)
+	logger.Subprocess("Target: %s", target)
+
+	resolutionError := func(err error) error {
+		constraint := version
+		if constraint == requestedVersion {
+			constraint = ""
+		}
+
+		return ResolutionError{
+			Version:       requestedVersion,
+			Constraint:    constraint,
+			VersionSource: versionSource,
+			Target:        target.String(),
+			Stack:         context.Stack,
+			Available:     config.availableDependencies(entry.Name),
+			Err:           err,
+		}
+	}
+
+	dependency, err := dependencyManager.Resolve(buildpackTOMLPath, entry.Name, version, context.Stack)
+	if err != nil {
+		return postal.Dependency{}, resolutionError(err)
+	}
+
+	dependency, err = config.selectDependency(dependency, version, target)
+	if err != nil {
+		return postal.Dependency{}, resolutionError(err)
+	}
+
+	if target.isMusl() && len(dependency.Distros) == 0 {
+		logger.Subprocess("WARNING: No musl build of %s %s is available, falling back to the glibc build", dependency.Name, dependency.Version)
+	}
+
+	// The deprecation warnings of scribe.Emitter.SelectedDependency have a
+	// fixed window, so the selection is logged here instead.
+	source := versionSource
+	if source == "" {
+		source = "<unknown>"
+	}
+	logger.Subprocess("Selected %s version (using %s): %s", dependency.Name, source, dependency.Version)
+
+	err = deprecation.check(logger, dependency, now)
+	if err != nil {
+		return postal.Dependency{}, err
+	}
+	logger.Break()
+
+	return dependency, nil
+}
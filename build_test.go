@@ -1,20 +1,28 @@
 package vsdbg_test
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/postal"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 	vsdbg "github.com/paketo-buildpacks/vsdbg"
 	"github.com/paketo-buildpacks/vsdbg/fakes"
 	"github.com/sclevine/spec"
@@ -31,9 +39,11 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		cnbDir     string
 		workingDir string
 
-		sbomGenerator     *fakes.SBOMGenerator
-		dependencyManager *fakes.DependencyManager
-		logEmitter        scribe.Emitter
+		sbomGenerator        *fakes.SBOMGenerator
+		bindingResolver      *fakes.BindingResolver
+		installationVerifier *fakes.InstallationVerifier
+		dependencyManager    *fakes.DependencyManager
+		logEmitter           scribe.Emitter
 
 		buffer *bytes.Buffer
 
@@ -52,6 +62,14 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		workingDir, err = os.MkdirTemp("", "working-dir")
 		Expect(err).NotTo(HaveOccurred())
 
+		err = os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte(`
+[metadata]
+  [[metadata.version-aliases]]
+    alias = "vs2022"
+    constraint = "17.4.11017"
+`), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
 		dependency := postal.Dependency{
 			ID:       "vsdbg",
 			Name:     "vsdbg-dependency-name",
@@ -91,8 +109,19 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		buffer = bytes.NewBuffer(nil)
 		logEmitter = scribe.NewEmitter(buffer)
 
+		bindingResolver = &fakes.BindingResolver{}
+		installationVerifier = &fakes.InstallationVerifier{}
+		installationVerifier.CheckGLIBCCall.Returns.GLIBCReport = vsdbg.GLIBCReport{
+			Libc:          "/lib/x86_64-linux-gnu/libc.so.6",
+			Version:       "GLIBC_2.35",
+			Distro:        "ubuntu",
+			DistroVersion: "22.04",
+		}
+
 		build = vsdbg.Build(
 			dependencyManager,
+			bindingResolver,
+			installationVerifier,
 			sbomGenerator,
 			logEmitter,
 			chronos.DefaultClock,
@@ -111,9 +140,10 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 					{Name: "vsdbg"},
 				},
 			},
-			Platform: packit.Platform{Path: "platform"},
-			Layers:   packit.Layers{Path: layersDir},
-			Stack:    "some-stack",
+			Platform:   packit.Platform{Path: "platform"},
+			Layers:     packit.Layers{Path: layersDir},
+			Stack:      "some-stack",
+			TargetInfo: packit.TargetInfo{OS: "linux", Arch: "amd64"},
 		}
 	})
 
@@ -134,9 +164,11 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 
 		Expect(layer.Path).To(Equal(filepath.Join(layersDir, "vsdbg")))
 
-		Expect(layer.SharedEnv).To(HaveLen(2))
+		Expect(layer.SharedEnv).To(HaveLen(4))
 		Expect(layer.SharedEnv["PATH.delim"]).To(Equal(":"))
 		Expect(layer.SharedEnv["PATH.append"]).To(Equal(filepath.Join(layersDir, "vsdbg")))
+		Expect(layer.SharedEnv["VSDBG_HOME.override"]).To(Equal(filepath.Join(layersDir, "vsdbg")))
+		Expect(layer.SharedEnv["VSDBG_VERSION.override"]).To(Equal("vsdbg-dependency-version"))
 
 		Expect(layer.BuildEnv).To(BeEmpty())
 		Expect(layer.LaunchEnv).To(BeEmpty())
@@ -146,8 +178,14 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(layer.Launch).To(BeFalse())
 		Expect(layer.Cache).To(BeFalse())
 
-		Expect(layer.Metadata).To(HaveLen(1))
+		Expect(result.Launch).To(Equal(packit.LaunchMetadata{}))
+
+		Expect(layer.Metadata).To(HaveLen(2))
 		Expect(layer.Metadata["dependency-checksum"]).To(Equal("sha256:vsdbg-dependency-sha"))
+		Expect(layer.Metadata["manifest"]).To(Equal(map[string]interface{}{
+			"digest": "sha256:823866beb86a9ccb970581ba108f2d8e8fdd659467f2950ffdce2a24a9082524",
+			"files":  1,
+		}))
 
 		Expect(layer.SBOM.Formats()).To(HaveLen(2))
 		cdx := layer.SBOM.Formats()[0]
@@ -235,167 +273,1652 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(dependencyManager.DeliverCall.Receives.DestinationPath).To(Equal(filepath.Join(layersDir, "vsdbg")))
 		Expect(dependencyManager.DeliverCall.Receives.PlatformPath).To(Equal("platform"))
 
-		Expect(sbomGenerator.GenerateCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "vsdbg")))
+		Expect(installationVerifier.VerifyCall.Receives.Path).To(Equal(filepath.Join(layersDir, "vsdbg")))
+		Expect(installationVerifier.VerifyCall.Receives.Arch).To(Equal("amd64"))
+		Expect(installationVerifier.CheckGLIBCCall.Receives.Path).To(Equal(filepath.Join(layersDir, "vsdbg")))
+		Expect(installationVerifier.CheckGLIBCCall.Receives.Arch).To(Equal("amd64"))
+
+		Expect(sbomGenerator.GenerateWithDependencyCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "vsdbg")))
+		Expect(sbomGenerator.GenerateWithDependencyCall.Receives.Dependency.Licenses).To(Equal([]string{"LicenseRef-vsdbg-EULA"}))
 
 		Expect(buffer.String()).To(ContainSubstring("Some Buildpack some-version"))
 		Expect(buffer.String()).To(ContainSubstring("Executing build process"))
 		Expect(buffer.String()).To(ContainSubstring("Installing Visual Studio Debugger"))
 
-		// test that the existing file permissions for vsdbg binary are preserved
-		// with the addition of owner and group-execute permissions
+		// test that the vsdbg binary is made executable, and that its
+		// permissions and modification time are normalized
 		info, err := os.Stat(filepath.Join(layersDir, "vsdbg", "vsdbg"))
 		Expect(err).NotTo(HaveOccurred())
-		Expect(info.Mode().String()).To(Equal("-rwxr-xr--"))
+		Expect(info.Mode().String()).To(Equal("-rwxr-xr-x"))
+		Expect(info.ModTime().UTC()).To(Equal(time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)))
+
+		info, err = os.Stat(filepath.Join(layersDir, "vsdbg"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ModTime().UTC()).To(Equal(time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)))
+
+		Expect(buffer.String()).To(ContainSubstring("Normalizing file times to 1980-01-01T00:00:01Z, ownership and permissions"))
 	})
 
-	context("when build plan entries require vsdbg at build/launch", func() {
+	context("when the extracted files have differing permissions and times", func() {
 		it.Before(func() {
-			buildContext.Plan.Entries[0].Metadata = make(map[string]interface{})
-			buildContext.Plan.Entries[0].Metadata["build"] = true
-			buildContext.Plan.Entries[0].Metadata["launch"] = true
+			t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+			deliver := dependencyManager.DeliverCall.Stub
+			dependencyManager.DeliverCall.Stub = func(dependency postal.Dependency, cnbDir, targetLayerPath, platformPath string) error {
+				err := deliver(dependency, cnbDir, targetLayerPath, platformPath)
+				if err != nil {
+					return err
+				}
+
+				libDir := filepath.Join(targetLayerPath, "lib")
+				err = os.Mkdir(libDir, 0700)
+				if err != nil {
+					return err
+				}
+
+				err = os.WriteFile(filepath.Join(libDir, "libvsdbg.so"), nil, 0700)
+				if err != nil {
+					return err
+				}
+
+				err = os.WriteFile(filepath.Join(libDir, "vsdbg.dll"), nil, 0600)
+				if err != nil {
+					return err
+				}
+
+				err = os.Symlink("libvsdbg.so", filepath.Join(libDir, "libvsdbg.so.1"))
+				if err != nil {
+					return err
+				}
+
+				return os.Chtimes(filepath.Join(libDir, "vsdbg.dll"), time.Now(), time.Now())
+			}
 		})
 
-		it("makes the layer available at the right times", func() {
-			result, err := build(buildContext)
+		it("normalizes them to SOURCE_DATE_EPOCH and fixed permissions", func() {
+			_, err := build(buildContext)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Layers).To(HaveLen(1))
-			layer := result.Layers[0]
-
-			Expect(layer.Name).To(Equal("vsdbg"))
-			Expect(layer.Path).To(Equal(filepath.Join(layersDir, "vsdbg")))
-			Expect(layer.Metadata).To(Equal(map[string]interface{}{
-				"dependency-checksum": "sha256:vsdbg-dependency-sha",
-			}))
+			sourceDate := time.Unix(1700000000, 0).UTC()
+			for path, mode := range map[string]string{
+				".":                 "drwxr-xr-x",
+				"vsdbg":             "-rwxr-xr-x",
+				"lib":               "drwxr-xr-x",
+				"lib/libvsdbg.so":   "-rwxr-xr-x",
+				"lib/vsdbg.dll":     "-rw-r--r--",
+				"lib/libvsdbg.so.1": "Lrwxrwxrwx",
+			} {
+				info, err := os.Lstat(filepath.Join(layersDir, "vsdbg", path))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Mode().String()).To(Equal(mode), path)
+				Expect(info.ModTime().UTC()).To(Equal(sourceDate), path)
+			}
 
-			Expect(layer.Build).To(BeTrue())
-			Expect(layer.Launch).To(BeTrue())
-			Expect(layer.Cache).To(BeTrue())
+			Expect(buffer.String()).To(ContainSubstring("Normalizing file times to 2023-11-14T22:13:20Z, ownership and permissions"))
 		})
 	})
 
-	context("when rebuilding a layer", func() {
+	context("when BP_VSDBG_VERSION is set", func() {
 		it.Before(func() {
-			err := os.WriteFile(filepath.Join(layersDir, fmt.Sprintf("%s.toml", vsdbg.PlanDependencyVSDBG)), []byte(`[metadata]
-dependency-checksum = "sha256:vsdbg-dependency-sha"
-			`), os.ModePerm)
+			t.Setenv("BP_VSDBG_VERSION", "17.4.*")
+
+			buildContext.Plan.Entries[0].Metadata = map[string]interface{}{
+				"version":        "18.*",
+				"version-source": "some-source",
+			}
+		})
+
+		it("resolves the version from the environment variable first", func() {
+			_, err := build(buildContext)
 			Expect(err).NotTo(HaveOccurred())
 
-			buildContext.Plan.Entries[0].Metadata = make(map[string]interface{})
-			buildContext.Plan.Entries[0].Metadata["build"] = true
-			buildContext.Plan.Entries[0].Metadata["launch"] = false
+			Expect(dependencyManager.ResolveCall.Receives.Version).To(Equal("17.4.*"))
+
+			Expect(buffer.String()).To(ContainSubstring("Candidate version sources (in priority order):"))
+			Expect(buffer.String()).To(MatchRegexp(`BP_VSDBG_VERSION\s+-> "17.4.\*"`))
+			Expect(buffer.String()).To(MatchRegexp(`some-source\s+-> "18.\*"`))
+			Expect(buffer.String()).To(ContainSubstring("Selected vsdbg-dependency-name version (using BP_VSDBG_VERSION): vsdbg-dependency-version"))
 		})
 
-		it("skips the build process if the cached dependency sha matches the selected dependency sha", func() {
-			result, err := build(buildContext)
+		it("does not add the version to the plan of the build context", func() {
+			entries := make([]packit.BuildpackPlanEntry, 1, 2)
+			copy(entries, buildContext.Plan.Entries)
+			buildContext.Plan.Entries = entries
+
+			_, err := build(buildContext)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Layers).To(HaveLen(1))
-			layer := result.Layers[0]
+			Expect(buildContext.Plan.Entries).To(HaveLen(1))
+			Expect(entries[:2][1]).To(Equal(packit.BuildpackPlanEntry{}))
+		})
 
-			Expect(layer.Name).To(Equal("vsdbg"))
+		context("when BP_VSDBG_VERSION is empty", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_VERSION", "  ")
+			})
 
-			Expect(layer.Build).To(BeTrue())
-			Expect(layer.Launch).To(BeFalse())
-			Expect(layer.Cache).To(BeTrue())
+			it("resolves the version from the other sources", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
 
-			Expect(buffer.String()).ToNot(ContainSubstring("Executing build process"))
-			Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
+				Expect(dependencyManager.ResolveCall.Receives.Version).To(Equal("18.*"))
+				Expect(buffer.String()).NotTo(ContainSubstring("BP_VSDBG_VERSION"))
+				Expect(buffer.String()).To(ContainSubstring("Selected vsdbg-dependency-name version (using some-source): vsdbg-dependency-version"))
+			})
+		})
+	})
 
-			Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+	context("when the version is declared in the application source", func() {
+		it.Before(func() {
+			buildContext.Plan.Entries = []packit.BuildpackPlanEntry{
+				{
+					Name: "vsdbg",
+					Metadata: map[string]interface{}{
+						"version":        "17.2.*",
+						"version-source": "buildpack.yml",
+					},
+				},
+				{
+					Name: "vsdbg",
+					Metadata: map[string]interface{}{
+						"version":        "17.4.*",
+						"version-source": "app.csproj",
+					},
+				},
+			}
+		})
+
+		it("prefers the project file over buildpack.yml", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dependencyManager.ResolveCall.Receives.Version).To(Equal("17.4.*"))
+			Expect(buffer.String()).To(ContainSubstring("Selected vsdbg-dependency-name version (using app.csproj): vsdbg-dependency-version"))
 		})
 	})
 
-	context("failure cases", func() {
-		context("when dependency resolution fails", func() {
+	context("when the requested version is a meta version", func() {
+		it.Before(func() {
+			buildContext.Plan.Entries[0].Metadata = map[string]interface{}{
+				"version":        "VS2022",
+				"version-source": "some-source",
+			}
+		})
+
+		it("resolves the constraint the meta version maps to", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dependencyManager.ResolveCall.Receives.Version).To(Equal("17.4.11017"))
+			Expect(buffer.String()).To(ContainSubstring(`Resolved meta version "VS2022" to "17.4.11017"`))
+		})
+	})
+
+	context("when several builds differ only in their fourth version component", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte(`
+[metadata]
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    name = "vsdbg-dependency-name"
+    checksum = "sha256:revision-1-sha"
+    os = "linux"
+    arch = "amd64"
+    stacks = ["some-stack"]
+    version = "17.4.11017+1"
+
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    name = "vsdbg-dependency-name"
+    checksum = "sha256:revision-10-sha"
+    os = "linux"
+    arch = "amd64"
+    stacks = ["some-stack"]
+    version = "17.4.11017+10"
+
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    name = "vsdbg-dependency-name"
+    checksum = "sha256:revision-2-sha"
+    os = "linux"
+    arch = "amd64"
+    stacks = ["some-stack"]
+    version = "17.4.11017+2"
+
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    name = "vsdbg-dependency-name"
+    checksum = "sha256:other-arch-sha"
+    os = "linux"
+    arch = "arm64"
+    stacks = ["some-stack"]
+    version = "17.4.11017+20"
+`), 0600)).To(Succeed())
+
+			dependencyManager.ResolveCall.Returns.Dependency = postal.Dependency{
+				ID:       "vsdbg",
+				Name:     "vsdbg-dependency-name",
+				Checksum: "sha256:revision-2-sha",
+				OS:       "linux",
+				Arch:     "amd64",
+				Stacks:   []string{"some-stack"},
+				Version:  "17.4.11017+2",
+			}
+		})
+
+		it("installs the build with the highest fourth component", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dependencyManager.DeliverCall.Receives.Dependency.Version).To(Equal("17.4.11017+10"))
+			Expect(result.Layers[0].Metadata["dependency-checksum"]).To(Equal("sha256:revision-10-sha"))
+		})
+
+		context("when building for another architecture", func() {
 			it.Before(func() {
-				dependencyManager.ResolveCall.Returns.Error = errors.New("failed to resolve dependency")
+				buildContext.TargetInfo = packit.TargetInfo{OS: "linux", Arch: "arm64"}
 			})
-			it("returns an error", func() {
+
+			it("installs the build for the target architecture", func() {
 				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
 
-				Expect(err).To(MatchError(ContainSubstring("failed to resolve dependency")))
+				Expect(dependencyManager.DeliverCall.Receives.Dependency.Arch).To(Equal("arm64"))
+				Expect(installationVerifier.VerifyCall.Receives.Arch).To(Equal("arm64"))
+				Expect(dependencyManager.DeliverCall.Receives.Dependency.Checksum).To(Equal("sha256:other-arch-sha"))
+				Expect(buffer.String()).To(ContainSubstring("Target: linux/arm64"))
 			})
 		})
 
-		context("when vsdbg layer cannot be fetched", func() {
+		context("when the requested version is a w.x.y.z upstream version", func() {
 			it.Before(func() {
-				Expect(os.Chmod(layersDir, 0000)).To(Succeed())
+				buildContext.Plan.Entries[0].Metadata = map[string]interface{}{
+					"version":        "17.4.11017.1",
+					"version-source": "some-source",
+				}
 			})
 
-			it.After(func() {
-				Expect(os.Chmod(layersDir, os.ModePerm)).To(Succeed())
+			it("installs exactly the requested build", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencyManager.ResolveCall.Receives.Version).To(Equal("17.4.11017+1"))
+				Expect(dependencyManager.DeliverCall.Receives.Dependency.Version).To(Equal("17.4.11017+1"))
+			})
+		})
+	})
+
+	context("when dependencies are restricted to target distributions", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte(`
+[metadata]
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    checksum = "sha256:jammy-sha"
+    os = "linux"
+    arch = "amd64"
+    stacks = ["*"]
+    version = "17.4.11017+1"
+
+    [[metadata.dependencies.distros]]
+      name = "ubuntu"
+      version = "22.04"
+
+`), 0600)).To(Succeed())
+
+			dependencyManager.ResolveCall.Returns.Dependency = postal.Dependency{
+				ID:       "vsdbg",
+				Checksum: "sha256:jammy-sha",
+				OS:       "linux",
+				Arch:     "amd64",
+				Stacks:   []string{"*"},
+				Version:  "17.4.11017+1",
+				Distros:  []postal.Distro{{Name: "ubuntu", Version: "22.04"}},
+			}
+
+			buildContext.TargetDistro = packit.TargetDistro{Name: "ubuntu", Version: "22.04"}
+		})
+
+		it("installs the build for the target distribution", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dependencyManager.DeliverCall.Receives.Dependency.Checksum).To(Equal("sha256:jammy-sha"))
+			Expect(buffer.String()).To(ContainSubstring("Target: linux/amd64 (ubuntu 22.04)"))
+		})
+
+		context("when no build supports the target distribution", func() {
+			it.Before(func() {
+				buildContext.TargetDistro = packit.TargetDistro{Name: "ubuntu", Version: "24.04"}
 			})
 
 			it("returns an error", func() {
 				_, err := build(buildContext)
-
-				Expect(err).To(MatchError(ContainSubstring("permission denied")))
+				Expect(err).To(MatchError(ContainSubstring("no vsdbg 17.4.11017+1 dependency supports the linux/amd64 (ubuntu 24.04) target")))
 			})
 		})
+	})
+
+	context("when the target distribution uses musl libc", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte(`
+[metadata]
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    name = "vsdbg-dependency-name"
+    checksum = "sha256:glibc-sha"
+    os = "linux"
+    arch = "amd64"
+    stacks = ["*"]
+    version = "17.4.11017+1"
+
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    name = "vsdbg-dependency-name"
+    checksum = "sha256:musl-sha"
+    os = "linux"
+    arch = "amd64"
+    version = "17.4.11017+1"
+
+    [[metadata.dependencies.distros]]
+      name = "alpine"
+`), 0600)).To(Succeed())
+
+			dependencyManager.ResolveCall.Returns.Dependency = postal.Dependency{
+				ID:       "vsdbg",
+				Name:     "vsdbg-dependency-name",
+				Checksum: "sha256:glibc-sha",
+				OS:       "linux",
+				Arch:     "amd64",
+				Stacks:   []string{"*"},
+				Version:  "17.4.11017+1",
+			}
+
+			buildContext.TargetDistro.Name = "alpine"
+		})
 
-		context("when vsdbg layer cannot be reset", func() {
+		it("installs the musl build of the debugger", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dependencyManager.ResolveCall.Receives.Stack).To(Equal("some-stack"))
+			Expect(dependencyManager.DeliverCall.Receives.Dependency.Checksum).To(Equal("sha256:musl-sha"))
+			Expect(dependencyManager.DeliverCall.Receives.Dependency.Distros).To(Equal([]postal.Distro{{Name: "alpine"}}))
+			Expect(buffer.String()).NotTo(ContainSubstring("No musl build"))
+		})
+
+		it("does not check glibc compatibility", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(installationVerifier.CheckGLIBCCall.CallCount).To(Equal(0))
+		})
+
+		context("when only the stack id names a musl distribution", func() {
 			it.Before(func() {
-				Expect(os.MkdirAll(filepath.Join(layersDir, vsdbg.PlanDependencyVSDBG), os.ModePerm))
-				Expect(os.Chmod(layersDir, 0500)).To(Succeed())
+				buildContext.TargetDistro.Name = ""
+				buildContext.Stack = "io.buildpacks.stacks.alpine"
 			})
 
-			it.After(func() {
-				Expect(os.Chmod(layersDir, os.ModePerm)).To(Succeed())
+			it("installs the glibc build of the debugger", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencyManager.ResolveCall.Receives.Stack).To(Equal("io.buildpacks.stacks.alpine"))
+				Expect(dependencyManager.DeliverCall.Receives.Dependency.Checksum).To(Equal("sha256:glibc-sha"))
+				Expect(installationVerifier.CheckGLIBCCall.CallCount).To(Equal(1))
 			})
+		})
 
-			it("returns an error", func() {
+		context("when there is no musl build of the debugger", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte(`
+[metadata]
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    name = "vsdbg-dependency-name"
+    checksum = "sha256:glibc-sha"
+    os = "linux"
+    arch = "amd64"
+    stacks = ["*"]
+    version = "17.4.11017+1"
+`), 0600)).To(Succeed())
+			})
+
+			it("warns that the glibc build is used", func() {
 				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
 
-				Expect(err).To(MatchError(ContainSubstring("permission denied")))
+				Expect(dependencyManager.DeliverCall.Receives.Dependency.Checksum).To(Equal("sha256:glibc-sha"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: No musl build of vsdbg-dependency-name 17.4.11017+1 is available, falling back to the glibc build"))
 			})
 		})
+	})
 
-		context("when dependency cannot be installed", func() {
+	context("when BP_VSDBG_SBOM_STRATEGY is dependency", func() {
+		it.Before(func() {
+			t.Setenv("BP_VSDBG_SBOM_STRATEGY", "dependency")
+		})
+
+		it("derives the SBOM from the dependency without scanning the layer", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sbomGenerator.GenerateCall.CallCount).To(Equal(0))
+			Expect(sbomGenerator.GenerateWithDependencyCall.CallCount).To(Equal(0))
+			Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "vsdbg")))
+			Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dependency).To(Equal(postal.Dependency{
+				ID:       "vsdbg",
+				Name:     "vsdbg-dependency-name",
+				Checksum: "sha256:vsdbg-dependency-sha",
+				Stacks:   []string{"some-stack"},
+				URI:      "vsdbg-dependency-uri",
+				Version:  "vsdbg-dependency-version",
+				PURL:     "pkg:generic/vsdbg@vsdbg-dependency-version?checksum=vsdbg-dependency-sha",
+				Licenses: []string{"LicenseRef-vsdbg-EULA"},
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Using the dependency SBOM strategy"))
+		})
+
+		context("when the dependency has a package URL", func() {
 			it.Before(func() {
-				dependencyManager.DeliverCall.Stub = func(dependency postal.Dependency, cnbDir, targetLayerPath, platformPath string) error {
-					return errors.New("failed to install dependency")
-				}
+				dependencyManager.ResolveCall.Returns.Dependency.PURL = "pkg:generic/vsdbg@17.4.11017.1?checksum=some-sha"
+				dependencyManager.ResolveCall.Returns.Dependency.CPE = "cpe:2.3:a:microsoft:vsdbg:17.4.11017.1:*:*:*:*:*:*:*"
 			})
-			it("returns an error", func() {
+
+			it("records the package URL and CPE from buildpack.toml", func() {
 				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
 
-				Expect(err).To(MatchError(ContainSubstring("failed to install dependency")))
+				dependency := sbomGenerator.GenerateFromDependencyCall.Receives.Dependency
+				Expect(dependency.PURL).To(Equal("pkg:generic/vsdbg@17.4.11017.1?checksum=some-sha"))
+				Expect(dependency.CPE).To(Equal("cpe:2.3:a:microsoft:vsdbg:17.4.11017.1:*:*:*:*:*:*:*"))
 			})
 		})
 
-		context("when dependency cannot be installed", func() {
+		context("when the dependency has licenses", func() {
 			it.Before(func() {
-				dependencyManager.DeliverCall.Stub = nil
+				dependencyManager.ResolveCall.Returns.Dependency.Licenses = []string{"LicenseRef-vsdbg-EULA-0123456789ab"}
 			})
-			it("returns an error", func() {
+
+			it("records the licenses from buildpack.toml", func() {
 				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
 
-				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+				Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dependency.Licenses).To(Equal([]string{"LicenseRef-vsdbg-EULA-0123456789ab"}))
 			})
 		})
 
-		context("when generating the SBOM returns an error", func() {
+		context("when generating the SBOM fails", func() {
 			it.Before(func() {
-				buildContext.BuildpackInfo.SBOMFormats = []string{"random-format"}
+				sbomGenerator.GenerateFromDependencyCall.Returns.Error = errors.New("failed to generate sbom from dependency")
 			})
 
 			it("returns an error", func() {
 				_, err := build(buildContext)
-				Expect(err).To(MatchError(`unsupported SBOM format: 'random-format'`))
+				Expect(err).To(MatchError("failed to generate sbom from dependency"))
 			})
 		})
+	})
+
+	context("when BP_VSDBG_SBOM_STRATEGY is scan", func() {
+		it.Before(func() {
+			t.Setenv("BP_VSDBG_SBOM_STRATEGY", "scan")
+		})
 
-		context("when formatting the sbom returns an error", func() {
+		it("only scans the layer", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sbomGenerator.GenerateFromDependencyCall.CallCount).To(Equal(0))
+			Expect(sbomGenerator.GenerateWithDependencyCall.CallCount).To(Equal(0))
+			Expect(sbomGenerator.GenerateCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "vsdbg")))
+
+			Expect(buffer.String()).To(ContainSubstring("Using the scan SBOM strategy"))
+		})
+	})
+
+	context("when BP_VSDBG_SBOM_STRATEGY is both", func() {
+		it.Before(func() {
+			t.Setenv("BP_VSDBG_SBOM_STRATEGY", "both")
+		})
+
+		it("scans the layer and adds the dependency", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sbomGenerator.GenerateCall.CallCount).To(Equal(0))
+			Expect(sbomGenerator.GenerateFromDependencyCall.CallCount).To(Equal(0))
+			Expect(sbomGenerator.GenerateWithDependencyCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "vsdbg")))
+			Expect(sbomGenerator.GenerateWithDependencyCall.Receives.Dependency.Name).To(Equal("vsdbg-dependency-name"))
+			Expect(sbomGenerator.GenerateWithDependencyCall.Receives.Dependency.PURL).To(Equal("pkg:generic/vsdbg@vsdbg-dependency-version?checksum=vsdbg-dependency-sha"))
+		})
+	})
+
+	context("when the debugger is required at launch", func() {
+		it.Before(func() {
+			buildContext.Plan.Entries[0].Metadata = map[string]interface{}{"launch": true}
+			dependencyManager.ResolveCall.Returns.Dependency.Source = "vsdbg-dependency-source"
+		})
+
+		it("labels the image with the installed debugger", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[0].Launch).To(BeTrue())
+			Expect(result.Launch.Labels).To(Equal(map[string]string{
+				"io.paketo.vsdbg.version":  "vsdbg-dependency-version",
+				"io.paketo.vsdbg.arch":     "amd64",
+				"io.paketo.vsdbg.checksum": "sha256:vsdbg-dependency-sha",
+				"io.paketo.vsdbg.source":   "vsdbg-dependency-source",
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Configuring image labels"))
+			Expect(buffer.String()).To(ContainSubstring(`io.paketo.vsdbg.version  -> "vsdbg-dependency-version"`))
+		})
+
+		context("when the dependency has no source", func() {
 			it.Before(func() {
-				sbomGenerator.GenerateCall.Returns.Error = errors.New("failed to generate sbom")
+				dependencyManager.ResolveCall.Returns.Dependency.Source = ""
 			})
 
-			it("returns an error", func() {
-				_, err := build(buildContext)
-				Expect(err).To(MatchError(ContainSubstring("failed to generate sbom")))
+			it("labels the image with the URI it was installed from", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Labels["io.paketo.vsdbg.source"]).To(Equal("vsdbg-dependency-uri"))
 			})
 		})
 
+		context("when the dependency has a four component version", func() {
+			it.Before(func() {
+				dependencyManager.ResolveCall.Returns.Dependency.Version = "18.7.10521+2"
+			})
+
+			it("exposes the upstream form of the version", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers[0].SharedEnv["VSDBG_VERSION.override"]).To(Equal("18.7.10521.2"))
+				Expect(result.Launch.Labels["io.paketo.vsdbg.version"]).To(Equal("18.7.10521.2"))
+			})
+		})
 	})
+
+	context("when BP_VSDBG_TRIM is set", func() {
+		it.Before(func() {
+			t.Setenv("BP_VSDBG_TRIM", "locales, Symbols")
+
+			deliver := dependencyManager.DeliverCall.Stub
+			dependencyManager.DeliverCall.Stub = func(dependency postal.Dependency, cnbDir, targetLayerPath, platformPath string) error {
+				err := deliver(dependency, cnbDir, targetLayerPath, platformPath)
+				if err != nil {
+					return err
+				}
+
+				for path, content := range map[string]string{
+					"de/vsdbg.resources.dll":    "de-resources",
+					"ja/vsdbg.resources.dll":    "ja-resources",
+					"pt-BR/vsdbg.resources.dll": "pt-BR-resources",
+					"lib/libvsdbg.so":           "library",
+					"lib/libvsdbg.so.dbg":       "library-symbols",
+					"vsdbg.pdb":                 "symbols",
+				} {
+					err = os.MkdirAll(filepath.Dir(filepath.Join(targetLayerPath, path)), os.ModePerm)
+					if err != nil {
+						return err
+					}
+
+					err = os.WriteFile(filepath.Join(targetLayerPath, path), []byte(content), 0644)
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			}
+		})
+
+		it("removes the localized resources and symbol files from the layer", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			layerPath := filepath.Join(layersDir, "vsdbg")
+			Expect(filepath.Join(layerPath, "de")).NotTo(BeADirectory())
+			Expect(filepath.Join(layerPath, "ja")).NotTo(BeADirectory())
+			Expect(filepath.Join(layerPath, "pt-BR")).NotTo(BeADirectory())
+			Expect(filepath.Join(layerPath, "vsdbg.pdb")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(layerPath, "lib", "libvsdbg.so.dbg")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(layerPath, "lib", "libvsdbg.so")).To(BeARegularFile())
+			Expect(filepath.Join(layerPath, "vsdbg")).To(BeARegularFile())
+
+			Expect(result.Layers[0].Metadata["trim"]).To(Equal("locales,symbols"))
+			Expect(result.Layers[0].Metadata["manifest"]).To(HaveKeyWithValue("files", 2))
+
+			Expect(buffer.String()).To(ContainSubstring("Trimming the layer: locales,symbols"))
+			Expect(buffer.String()).To(ContainSubstring("Removed 5 files (0.0 MB)"))
+		})
+
+		context("when BP_VSDBG_TRIM_CULTURES is set", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_TRIM", "")
+				t.Setenv("BP_VSDBG_TRIM_CULTURES", "JA,pt-br")
+			})
+
+			it("keeps the localized resources of the given cultures", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				layerPath := filepath.Join(layersDir, "vsdbg")
+				Expect(filepath.Join(layerPath, "de")).NotTo(BeADirectory())
+				Expect(filepath.Join(layerPath, "ja", "vsdbg.resources.dll")).To(BeARegularFile())
+				Expect(filepath.Join(layerPath, "pt-BR", "vsdbg.resources.dll")).To(BeARegularFile())
+				Expect(filepath.Join(layerPath, "vsdbg.pdb")).To(BeARegularFile())
+
+				Expect(result.Layers[0].Metadata["trim"]).To(Equal("locales:ja|pt-br"))
+			})
+		})
+
+		context("when a directory named like a culture holds other files", func() {
+			it.Before(func() {
+				deliver := dependencyManager.DeliverCall.Stub
+				dependencyManager.DeliverCall.Stub = func(dependency postal.Dependency, cnbDir, targetLayerPath, platformPath string) error {
+					err := deliver(dependency, cnbDir, targetLayerPath, platformPath)
+					if err != nil {
+						return err
+					}
+
+					return os.WriteFile(filepath.Join(targetLayerPath, "de", "vsdbg.dll"), nil, 0644)
+				}
+			})
+
+			it("keeps the directory", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(layersDir, "vsdbg", "de", "vsdbg.dll")).To(BeARegularFile())
+				Expect(filepath.Join(layersDir, "vsdbg", "ja")).NotTo(BeADirectory())
+			})
+		})
+	})
+
+	context("when the debugger needs a newer glibc than the stack provides", func() {
+		it.Before(func() {
+			installationVerifier.CheckGLIBCCall.Returns.GLIBCReport.Version = "GLIBC_2.28"
+			installationVerifier.CheckGLIBCCall.Returns.GLIBCReport.Missing = []vsdbg.MissingSymbolVersion{
+				{File: "vsdbg", Library: "libc.so.6", Symbol: "pthread_create", Version: "GLIBC_2.34"},
+				{File: "libvsdbg.so", Library: "libm.so.6", Symbol: "log2", Version: "GLIBC_2.29"},
+			}
+		})
+
+		it("warns about the missing symbol versions", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("WARNING: vsdbg needs symbol versions that are newer than GLIBC_2.28 provided by /lib/x86_64-linux-gnu/libc.so.6"))
+			Expect(buffer.String()).To(ContainSubstring("vsdbg imports pthread_create@GLIBC_2.34 from libc.so.6"))
+			Expect(buffer.String()).To(ContainSubstring("libvsdbg.so imports log2@GLIBC_2.29 from libm.so.6"))
+		})
+
+		context("when BP_VSDBG_GLIBC_CHECK is fail", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_GLIBC_CHECK", "fail")
+			})
+
+			it("returns an error listing the missing symbol versions", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(`vsdbg needs symbol versions that are newer than GLIBC_2.28 provided by /lib/x86_64-linux-gnu/libc.so.6:
+  vsdbg imports pthread_create@GLIBC_2.34 from libc.so.6
+  libvsdbg.so imports log2@GLIBC_2.29 from libm.so.6
+use a stack with a newer glibc, or set BP_VSDBG_GLIBC_CHECK to "warn" or "skip"`))
+
+				Expect(sbomGenerator.GenerateWithDependencyCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when BP_VSDBG_GLIBC_CHECK is skip", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_GLIBC_CHECK", "skip")
+			})
+
+			it("does not check glibc compatibility", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(installationVerifier.CheckGLIBCCall.CallCount).To(Equal(0))
+				Expect(buffer.String()).NotTo(ContainSubstring("glibc"))
+			})
+		})
+	})
+
+	context("when the run image distribution is the one of the build image", func() {
+		it.Before(func() {
+			buildContext.TargetDistro = packit.TargetDistro{Name: "ubuntu", Version: "22.04"}
+		})
+
+		it("does not warn about the glibc check", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).NotTo(ContainSubstring("WARNING: The glibc check used the build image"))
+		})
+	})
+
+	context("when the run image distribution differs from the build image", func() {
+		it.Before(func() {
+			buildContext.TargetDistro = packit.TargetDistro{Name: "ubuntu", Version: "24.04"}
+		})
+
+		it("warns that the glibc check may not apply to the run image", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("WARNING: The glibc check used the build image (ubuntu 22.04), but the run image is ubuntu 24.04"))
+			Expect(buffer.String()).To(ContainSubstring("Its result may not apply to the run image, which may provide another glibc version."))
+		})
+
+		context("when the build image has no os-release file", func() {
+			it.Before(func() {
+				installationVerifier.CheckGLIBCCall.Returns.GLIBCReport.Distro = ""
+				installationVerifier.CheckGLIBCCall.Returns.GLIBCReport.DistroVersion = ""
+			})
+
+			it("warns that the build image distribution is unknown", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("WARNING: The glibc check used the build image (an unknown distribution), but the run image is ubuntu 24.04"))
+			})
+		})
+	})
+
+	context("when the stack does not provide glibc", func() {
+		it.Before(func() {
+			installationVerifier.CheckGLIBCCall.Returns.GLIBCReport = vsdbg.GLIBCReport{}
+		})
+
+		it("warns that the check is skipped", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("WARNING: No glibc was found on the stack, skipping the glibc compatibility check"))
+		})
+	})
+
+	context("when the selected dependency has a deprecation date", func() {
+		it("warns when the deprecation date is within the default window", func() {
+			dependencyManager.ResolveCall.Returns.Dependency.DeprecationDate = time.Now().Add(10 * 24 * time.Hour)
+
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("Version vsdbg-dependency-version of vsdbg-dependency-name will be deprecated after"))
+			Expect(buffer.String()).To(ContainSubstring("Migrate your application to a supported version of vsdbg-dependency-name before this time."))
+		})
+
+		it("does not warn when the deprecation date is outside of the window", func() {
+			dependencyManager.ResolveCall.Returns.Dependency.DeprecationDate = time.Now().Add(40 * 24 * time.Hour)
+
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).NotTo(ContainSubstring("deprecated"))
+		})
+
+		it("warns when the deprecation date has passed", func() {
+			dependencyManager.ResolveCall.Returns.Dependency.DeprecationDate = time.Now().Add(-24 * time.Hour)
+
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("Version vsdbg-dependency-version of vsdbg-dependency-name is deprecated."))
+		})
+
+		context("when BP_VSDBG_DEPRECATION_WARNING_DAYS is set", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_DEPRECATION_WARNING_DAYS", "60")
+				dependencyManager.ResolveCall.Returns.Dependency.DeprecationDate = time.Now().Add(40 * 24 * time.Hour)
+			})
+
+			it("warns within the configured window", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("will be deprecated after"))
+			})
+		})
+
+		context("when BP_VSDBG_FAIL_ON_DEPRECATED is true", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_FAIL_ON_DEPRECATED", "true")
+			})
+
+			it("fails when the deprecation date has passed", func() {
+				dependencyManager.ResolveCall.Returns.Dependency.DeprecationDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("version vsdbg-dependency-version of vsdbg-dependency-name is deprecated since 2024-01-01 and BP_VSDBG_FAIL_ON_DEPRECATED is true"))
+			})
+
+			it("fails when the deprecation date is within the window", func() {
+				dependencyManager.ResolveCall.Returns.Dependency.DeprecationDate = time.Now().Add(10 * 24 * time.Hour)
+
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("will be deprecated after")))
+			})
+
+			it("succeeds when the deprecation date is outside of the window", func() {
+				dependencyManager.ResolveCall.Returns.Dependency.DeprecationDate = time.Now().Add(40 * 24 * time.Hour)
+
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	context("when a local archive is configured", func() {
+		var checksum string
+
+		it.Before(func() {
+			checksum = writeArchive(t, filepath.Join(workingDir, "vsdbg-linux-x64.tar.gz"))
+
+			t.Setenv("BP_VSDBG_ARCHIVE", "vsdbg-linux-x64.tar.gz")
+			t.Setenv("BP_VSDBG_ARCHIVE_CHECKSUM", checksum)
+		})
+
+		it("installs the debugger from the archive", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dependencyManager.ResolveCall.CallCount).To(Equal(0))
+			Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+			Expect(bindingResolver.ResolveCall.CallCount).To(Equal(0))
+
+			layer := result.Layers[0]
+			Expect(layer.Metadata).To(Equal(map[string]interface{}{
+				"dependency-checksum": "sha256:" + checksum,
+				"archive-source":      "BP_VSDBG_ARCHIVE",
+				"manifest": map[string]interface{}{
+					"digest": "sha256:2bcf3ea62c39f08f67f5f59177e974d574d921599c1c8d4ed3883ca329e485c1",
+					"files":  1,
+				},
+			}))
+
+			info, err := os.Stat(filepath.Join(layersDir, "vsdbg", "vsdbg"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()).To(Equal(os.FileMode(0755)))
+
+			Expect(layer.SharedEnv["VSDBG_HOME.override"]).To(Equal(filepath.Join(layersDir, "vsdbg")))
+			Expect(layer.SharedEnv).NotTo(HaveKey("VSDBG_VERSION.override"))
+
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Selected vsdbg archive (using BP_VSDBG_ARCHIVE): %s", filepath.Join(workingDir, "vsdbg-linux-x64.tar.gz"))))
+			Expect(buffer.String()).To(ContainSubstring("Installing Visual Studio Debugger from"))
+			Expect(buffer.String()).NotTo(ContainSubstring("is ignored"))
+		})
+
+		context("when a version is requested", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_VERSION", "18.7.*")
+			})
+
+			it("warns that the version is ignored", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencyManager.ResolveCall.CallCount).To(Equal(0))
+				Expect(buffer.String()).To(ContainSubstring(`WARNING: The version "18.7.*" requested by BP_VSDBG_VERSION is ignored, as the archive configured by BP_VSDBG_ARCHIVE is installed`))
+			})
+		})
+
+		context("when the debugger is required at launch", func() {
+			it.Before(func() {
+				buildContext.Plan.Entries[0].Metadata = map[string]interface{}{"launch": true}
+			})
+
+			it("labels the image without a version", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Labels).To(Equal(map[string]string{
+					"io.paketo.vsdbg.arch":     "amd64",
+					"io.paketo.vsdbg.checksum": "sha256:" + checksum,
+					"io.paketo.vsdbg.source":   "BP_VSDBG_ARCHIVE",
+				}))
+			})
+		})
+
+		context("when BP_VSDBG_SBOM_STRATEGY is dependency", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_SBOM_STRATEGY", "dependency")
+			})
+
+			it("records the checksum of the archive in the package URL and the EULA license", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dependency.PURL).To(Equal("pkg:generic/vsdbg?checksum=" + checksum))
+				Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dependency.Licenses).To(Equal([]string{"LicenseRef-vsdbg-EULA"}))
+			})
+		})
+
+		context("when the archive was installed by a previous build", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layersDir, "vsdbg.toml"), []byte(fmt.Sprintf(`[metadata]
+dependency-checksum = "sha256:%s"
+
+[metadata.manifest]
+digest = "sha256:2bcf3ea62c39f08f67f5f59177e974d574d921599c1c8d4ed3883ca329e485c1"
+files = 1
+`, checksum)), 0600)).To(Succeed())
+
+				Expect(os.MkdirAll(filepath.Join(layersDir, "vsdbg"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "vsdbg", "vsdbg"), []byte("vsdbg"), 0755)).To(Succeed())
+			})
+
+			it("reuses the cached layer", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when no checksum is given", func() {
+				it.Before(func() {
+					Expect(os.Unsetenv("BP_VSDBG_ARCHIVE_CHECKSUM")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("BP_VSDBG_ARCHIVE_CHECKSUM must be set when BP_VSDBG_ARCHIVE is set"))
+				})
+			})
+
+			context("when the archive does not exist", func() {
+				it.Before(func() {
+					t.Setenv("BP_VSDBG_ARCHIVE", "missing.tar.gz")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring("failed to find archive configured by BP_VSDBG_ARCHIVE")))
+				})
+			})
+
+			context("when the checksum does not match", func() {
+				it.Before(func() {
+					t.Setenv("BP_VSDBG_ARCHIVE_CHECKSUM", "sha256:0000000000000000000000000000000000000000000000000000000000000000")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring("checksum does not match")))
+				})
+			})
+		})
+	})
+
+	context("when a vsdbg service binding provides an archive", func() {
+		it.Before(func() {
+			bindingDir := filepath.Join(workingDir, "bindings", "my-vsdbg")
+			Expect(os.MkdirAll(bindingDir, os.ModePerm)).To(Succeed())
+
+			checksum := writeArchive(t, filepath.Join(bindingDir, "archive"))
+
+			bindingResolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
+				{
+					Name: "my-vsdbg",
+					Path: bindingDir,
+					Type: "vsdbg",
+					Entries: map[string]*servicebindings.Entry{
+						"archive":  servicebindings.NewEntry(filepath.Join(bindingDir, "archive")),
+						"checksum": servicebindings.NewWithValue([]byte(checksum + "\n")),
+					},
+				},
+			}
+		})
+
+		it("installs the debugger from the archive", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(bindingResolver.ResolveCall.Receives.Typ).To(Equal("vsdbg"))
+			Expect(bindingResolver.ResolveCall.Receives.PlatformDir).To(Equal("platform"))
+			Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+
+			Expect(result.Layers[0].Metadata["archive-source"]).To(Equal("binding my-vsdbg"))
+			Expect(filepath.Join(layersDir, "vsdbg", "vsdbg")).To(BeARegularFile())
+		})
+
+		context("failure cases", func() {
+			context("when the bindings cannot be resolved", func() {
+				it.Before(func() {
+					bindingResolver.ResolveCall.Returns.Error = errors.New("some-binding-error")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("failed to resolve vsdbg binding: some-binding-error"))
+				})
+			})
+
+			context("when there is more than one binding", func() {
+				it.Before(func() {
+					bindingResolver.ResolveCall.Returns.BindingSlice = append(bindingResolver.ResolveCall.Returns.BindingSlice, servicebindings.Binding{Name: "other"})
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("found 2 bindings of type vsdbg: expected at most one"))
+				})
+			})
+
+			context("when the binding has no checksum", func() {
+				it.Before(func() {
+					delete(bindingResolver.ResolveCall.Returns.BindingSlice[0].Entries, "checksum")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("binding my-vsdbg of type vsdbg is missing the checksum entry"))
+				})
+			})
+
+			context("when the binding has no archive", func() {
+				it.Before(func() {
+					delete(bindingResolver.ResolveCall.Returns.BindingSlice[0].Entries, "archive")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("binding my-vsdbg of type vsdbg is missing the archive entry"))
+				})
+			})
+		})
+	})
+
+	context("when a dependency mirror is configured", func() {
+		var (
+			mirror        *httptest.Server
+			requestedPath string
+			defaultClient *http.Client
+		)
+
+		it.Before(func() {
+			archivePath := filepath.Join(t.TempDir(), "vsdbg-linux-x64.tar.gz")
+			checksum := writeArchive(t, archivePath)
+
+			mirror = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requestedPath = req.URL.Path
+				http.ServeFile(w, req, archivePath)
+			}))
+
+			// The transport of the dependency manager downloads with the default
+			// client, which has to trust the certificate of the mirror.
+			defaultClient = http.DefaultClient
+			http.DefaultClient = mirror.Client()
+
+			Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte(fmt.Sprintf(`
+[metadata]
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    name = "Visual Studio Debugger"
+    checksum = "sha256:%s"
+    stacks = ["*"]
+    uri = "https://vsdebugger.example.com/vsdbg-17-4-11017-1/vsdbg-linux-x64.tar.gz"
+    version = "17.4.11017+1"
+`, checksum)), 0600)).To(Succeed())
+
+			build = vsdbg.Build(
+				postal.NewService(cargo.NewTransport()),
+				bindingResolver,
+				installationVerifier,
+				sbomGenerator,
+				logEmitter,
+				chronos.DefaultClock,
+			)
+		})
+
+		it.After(func() {
+			http.DefaultClient = defaultClient
+			mirror.Close()
+		})
+
+		it("downloads the dependency from the mirror", func() {
+			t.Setenv("BP_DEPENDENCY_MIRROR", mirror.URL+"/{originalHost}")
+
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requestedPath).To(Equal("/vsdebugger.example.com/vsdbg-17-4-11017-1/vsdbg-linux-x64.tar.gz"))
+			Expect(filepath.Join(result.Layers[0].Path, "vsdbg")).To(BeARegularFile())
+		})
+
+		it("rewrites the path prefix of the dependency", func() {
+			t.Setenv("BP_DEPENDENCY_MIRROR_VSDEBUGGER_EXAMPLE_COM", fmt.Sprintf("mirror=%s/vsdbg,skip-path=/vsdbg-17-4-11017-1", mirror.URL))
+
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requestedPath).To(Equal("/vsdbg/vsdbg-linux-x64.tar.gz"))
+		})
+
+		context("when the mirror serves a different archive", func() {
+			it.Before(func() {
+				mirror.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					fmt.Fprint(w, "not the debugger")
+				})
+			})
+
+			it("fails to verify the checksum of the original dependency", func() {
+				t.Setenv("BP_DEPENDENCY_MIRROR", mirror.URL)
+
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("checksum does not match")))
+			})
+		})
+	})
+
+	context("when build plan entries require vsdbg at build/launch", func() {
+		it.Before(func() {
+			buildContext.Plan.Entries[0].Metadata = make(map[string]interface{})
+			buildContext.Plan.Entries[0].Metadata["build"] = true
+			buildContext.Plan.Entries[0].Metadata["launch"] = true
+		})
+
+		it("makes the layer available at the right times", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(1))
+			layer := result.Layers[0]
+
+			Expect(layer.Name).To(Equal("vsdbg"))
+			Expect(layer.Path).To(Equal(filepath.Join(layersDir, "vsdbg")))
+			Expect(layer.Metadata).To(Equal(map[string]interface{}{
+				"dependency-checksum": "sha256:vsdbg-dependency-sha",
+				"manifest": map[string]interface{}{
+					"digest": "sha256:823866beb86a9ccb970581ba108f2d8e8fdd659467f2950ffdce2a24a9082524",
+					"files":  1,
+				},
+			}))
+
+			Expect(layer.Build).To(BeTrue())
+			Expect(layer.Launch).To(BeTrue())
+			Expect(layer.Cache).To(BeTrue())
+		})
+	})
+
+	context("when rebuilding a layer", func() {
+		it.Before(func() {
+			err := os.WriteFile(filepath.Join(layersDir, fmt.Sprintf("%s.toml", vsdbg.PlanDependencyVSDBG)), []byte(`[metadata]
+dependency-checksum = "sha256:vsdbg-dependency-sha"
+
+[metadata.manifest]
+digest = "sha256:5013947046c41bf2d6b811e9d1422aa91c7a32d89eda6b2e90fa458d8ca45f3d"
+files = 2
+			`), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.MkdirAll(filepath.Join(layersDir, "vsdbg", "lib"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layersDir, "vsdbg", "vsdbg"), []byte("vsdbg"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layersDir, "vsdbg", "lib", "libvsdbg.so"), nil, 0644)).To(Succeed())
+
+			buildContext.Plan.Entries[0].Metadata = make(map[string]interface{})
+			buildContext.Plan.Entries[0].Metadata["build"] = true
+			buildContext.Plan.Entries[0].Metadata["launch"] = false
+		})
+
+		it("skips the build process if the cached dependency sha matches the selected dependency sha", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(1))
+			layer := result.Layers[0]
+
+			Expect(layer.Name).To(Equal("vsdbg"))
+
+			Expect(layer.Build).To(BeTrue())
+			Expect(layer.Launch).To(BeFalse())
+			Expect(layer.Cache).To(BeTrue())
+
+			Expect(buffer.String()).ToNot(ContainSubstring("Executing build process"))
+			Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
+
+			Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+
+			Expect(layer.SharedEnv["VSDBG_HOME.override"]).To(Equal(filepath.Join(layersDir, "vsdbg")))
+			Expect(layer.SharedEnv["VSDBG_VERSION.override"]).To(Equal("vsdbg-dependency-version"))
+			Expect(result.Launch).To(Equal(packit.LaunchMetadata{}))
+		})
+
+		context("when the debugger is required at launch", func() {
+			it.Before(func() {
+				buildContext.Plan.Entries[0].Metadata["launch"] = true
+			})
+
+			it("labels the image with the cached debugger", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
+				Expect(result.Launch.Labels).To(HaveKeyWithValue("io.paketo.vsdbg.version", "vsdbg-dependency-version"))
+				Expect(result.Launch.Labels).To(HaveKeyWithValue("io.paketo.vsdbg.checksum", "sha256:vsdbg-dependency-sha"))
+			})
+		})
+
+		context("when a file of the layer is missing", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(layersDir, "vsdbg", "lib", "libvsdbg.so"))).To(Succeed())
+			})
+
+			it("reinstalls the layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Reinstalling cached layer %s: expected 2 files in the layer, found 1", filepath.Join(layersDir, "vsdbg"))))
+				Expect(buffer.String()).To(ContainSubstring("Executing build process"))
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+
+				Expect(filepath.Join(layersDir, "vsdbg", "lib")).NotTo(BeADirectory())
+				Expect(result.Layers[0].Metadata["manifest"]).To(Equal(map[string]interface{}{
+					"digest": "sha256:823866beb86a9ccb970581ba108f2d8e8fdd659467f2950ffdce2a24a9082524",
+					"files":  1,
+				}))
+			})
+		})
+
+		context("when a file of the layer has been modified", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layersDir, "vsdbg", "vsdbg"), []byte("edited"), 0755)).To(Succeed())
+			})
+
+			it("reinstalls the layer", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("the files of the layer have been modified"))
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+			})
+
+			context("when BP_VSDBG_CACHE_VALIDATION is presence", func() {
+				it.Before(func() {
+					t.Setenv("BP_VSDBG_CACHE_VALIDATION", "presence")
+				})
+
+				it("reuses the cached layer", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
+					Expect(dependencyManager.DeliverCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		context("when the layer was trimmed with a different profile", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_TRIM", "symbols")
+			})
+
+			it("reinstalls the layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring(`the trim profile changed from "" to "symbols"`))
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+				Expect(result.Layers[0].Metadata["trim"]).To(Equal("symbols"))
+			})
+		})
+
+		context("when the layer was cached without a manifest", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layersDir, "vsdbg.toml"), []byte(`[metadata]
+dependency-checksum = "sha256:vsdbg-dependency-sha"
+`), 0600)).To(Succeed())
+			})
+
+			it("reinstalls the layer", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("the layer has no manifest"))
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+			})
+		})
+
+		context("when the layer was cached with a manifest of every file", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layersDir, "vsdbg.toml"), []byte(`[metadata]
+dependency-checksum = "sha256:vsdbg-dependency-sha"
+
+[metadata.manifest]
+vsdbg = "sha256:9babdfe58c32f9ecf05b800b18a8a745587d3cfcf9e90bcc3b5c740ad1cb6147"
+"lib/libvsdbg.so" = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+`), 0600)).To(Succeed())
+			})
+
+			it("reinstalls the layer", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("the layer has no manifest"))
+				Expect(dependencyManager.DeliverCall.CallCount).To(Equal(1))
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		context("when buildpack.toml cannot be parsed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse buildpack.toml")))
+			})
+		})
+
+		context("when BP_VSDBG_DEPRECATION_WARNING_DAYS cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_DEPRECATION_WARNING_DAYS", "soon")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_VSDBG_DEPRECATION_WARNING_DAYS value "soon"`)))
+			})
+		})
+
+		context("when BP_VSDBG_FAIL_ON_DEPRECATED cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_FAIL_ON_DEPRECATED", "not-a-bool")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_VSDBG_FAIL_ON_DEPRECATED value "not-a-bool"`)))
+			})
+		})
+
+		context("when SOURCE_DATE_EPOCH cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(`failed to parse SOURCE_DATE_EPOCH value "yesterday": expected a non-negative number of seconds since the Unix epoch`))
+			})
+		})
+
+		context("when BP_VSDBG_TRIM cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_TRIM", "locales,docs")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(`failed to parse BP_VSDBG_TRIM value "locales,docs": unknown category "docs": expected "locales" or "symbols"`))
+			})
+		})
+
+		context("when BP_VSDBG_TRIM_CULTURES cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_TRIM_CULTURES", "de,../lib")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(`failed to parse BP_VSDBG_TRIM_CULTURES value "de,../lib": "../lib" is not a culture name`))
+			})
+		})
+
+		context("when BP_VSDBG_GLIBC_CHECK cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_GLIBC_CHECK", "sometimes")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(`failed to parse BP_VSDBG_GLIBC_CHECK value "sometimes": expected one of "warn", "fail" or "skip"`))
+			})
+		})
+
+		context("when BP_VSDBG_SBOM_STRATEGY cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_SBOM_STRATEGY", "guess")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(`failed to parse BP_VSDBG_SBOM_STRATEGY value "guess": expected one of "scan", "dependency" or "both"`))
+			})
+		})
+
+		context("when BP_VSDBG_CACHE_VALIDATION cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("BP_VSDBG_CACHE_VALIDATION", "thorough")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(`failed to parse BP_VSDBG_CACHE_VALIDATION value "thorough": expected one of "presence" or "hash"`))
+			})
+		})
+
+		context("when dependency resolution fails", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte(`
+[metadata]
+  [[metadata.version-aliases]]
+    alias = "vs2022"
+    constraint = "17.4.11017"
+
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    os = "linux"
+    arch = "amd64"
+    stacks = ["*"]
+    version = "17.4.11017+1"
+
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    os = "linux"
+    arch = "amd64"
+    version = "17.4.11017+1"
+
+    [[metadata.dependencies.distros]]
+      name = "alpine"
+
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    os = "linux"
+    arch = "arm64"
+    stacks = ["*"]
+    version = "17.4.11017+1"
+
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    os = "linux"
+    arch = "amd64"
+    stacks = ["*"]
+    version = "18.7.10521+2"
+`), 0600)).To(Succeed())
+
+				buildContext.Plan.Entries[0].Metadata = map[string]interface{}{
+					"version":        "vs2022",
+					"version-source": "BP_VSDBG_VERSION",
+				}
+
+				dependencyManager.ResolveCall.Returns.Error = errors.New("failed to resolve dependency")
+			})
+
+			it("returns a ResolutionError listing the available versions", func() {
+				_, err := build(buildContext)
+
+				var resolutionError vsdbg.ResolutionError
+				Expect(errors.As(err, &resolutionError)).To(BeTrue())
+				Expect(resolutionError).To(Equal(vsdbg.ResolutionError{
+					Version:       "vs2022",
+					Constraint:    "17.4.11017",
+					VersionSource: "BP_VSDBG_VERSION",
+					Target:        "linux/amd64",
+					Stack:         "some-stack",
+					Available: []vsdbg.AvailableDependency{
+						{Version: "18.7.10521+2", OS: "linux", Arch: "amd64"},
+						{Version: "17.4.11017+1", OS: "linux", Arch: "amd64"},
+						{Version: "17.4.11017+1", OS: "linux", Arch: "arm64"},
+					},
+					Err: errors.New("failed to resolve dependency"),
+				}))
+
+				Expect(err).To(MatchError(`failed to resolve vsdbg version "vs2022" (17.4.11017) requested by BP_VSDBG_VERSION for target linux/amd64 and stack "some-stack": failed to resolve dependency
+available versions:
+  18.7.10521+2 linux/amd64
+  17.4.11017+1 linux/amd64
+  17.4.11017+1 linux/arm64`))
+				Expect(errors.Unwrap(err)).To(MatchError("failed to resolve dependency"))
+			})
+		})
+
+		context("when vsdbg layer cannot be fetched", func() {
+			it.Before(func() {
+				Expect(os.Chmod(layersDir, 0000)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Chmod(layersDir, os.ModePerm)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+
+				Expect(err).To(MatchError(ContainSubstring("permission denied")))
+			})
+		})
+
+		context("when vsdbg layer cannot be reset", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(layersDir, vsdbg.PlanDependencyVSDBG), os.ModePerm))
+				Expect(os.Chmod(layersDir, 0500)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Chmod(layersDir, os.ModePerm)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+
+				Expect(err).To(MatchError(ContainSubstring("permission denied")))
+			})
+		})
+
+		context("when dependency cannot be installed", func() {
+			it.Before(func() {
+				dependencyManager.DeliverCall.Stub = func(dependency postal.Dependency, cnbDir, targetLayerPath, platformPath string) error {
+					return errors.New("failed to install dependency")
+				}
+			})
+			it("returns an error", func() {
+				_, err := build(buildContext)
+
+				Expect(err).To(MatchError(ContainSubstring("failed to install dependency")))
+			})
+		})
+
+		context("when dependency cannot be installed", func() {
+			it.Before(func() {
+				dependencyManager.DeliverCall.Stub = nil
+			})
+			it("returns an error", func() {
+				_, err := build(buildContext)
+
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+		})
+
+		context("when the installed debugger cannot be verified", func() {
+			it.Before(func() {
+				installationVerifier.VerifyCall.Returns.Error = errors.New("failed to verify vsdbg")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to verify vsdbg"))
+				Expect(sbomGenerator.GenerateWithDependencyCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when glibc compatibility cannot be checked", func() {
+			it.Before(func() {
+				installationVerifier.CheckGLIBCCall.Returns.Error = errors.New("failed to check glibc compatibility")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to check glibc compatibility"))
+			})
+		})
+
+		context("when generating the SBOM returns an error", func() {
+			it.Before(func() {
+				buildContext.BuildpackInfo.SBOMFormats = []string{"random-format"}
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(`unsupported SBOM format: 'random-format'`))
+			})
+		})
+
+		context("when formatting the sbom returns an error", func() {
+			it.Before(func() {
+				sbomGenerator.GenerateWithDependencyCall.Returns.Error = errors.New("failed to generate sbom")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("failed to generate sbom")))
+			})
+		})
+
+	})
+}
+
+// writeArchive writes a tar.gz archive containing a vsdbg file to the given
+// path and returns its SHA256 checksum.
+func writeArchive(t *testing.T, path string) string {
+	t.Helper()
+
+	buffer := bytes.NewBuffer(nil)
+	gw := gzip.NewWriter(buffer)
+	tw := tar.NewWriter(gw)
+
+	content := []byte("vsdbg")
+	if err := tw.WriteHeader(&tar.Header{Name: "vsdbg", Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, buffer.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(buffer.Bytes()))
 }
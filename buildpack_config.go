@@ -0,0 +1,152 @@
+package vsdbg
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+)
+
+// VersionAlias maps a Visual Studio meta version, such as vs2022, onto the
+// semantic version constraint of the debugger builds it represents.
+type VersionAlias struct {
+	Alias      string `toml:"alias"`
+	Constraint string `toml:"constraint"`
+}
+
+// buildpackConfig is the subset of buildpack.toml that the buildpack reads
+// at build time.
+type buildpackConfig struct {
+	Metadata struct {
+		VersionAliases []VersionAlias      `toml:"version-aliases"`
+		Dependencies   []postal.Dependency `toml:"dependencies"`
+	} `toml:"metadata"`
+}
+
+func parseBuildpackConfig(path string) (buildpackConfig, error) {
+	var config buildpackConfig
+
+	file, err := os.Open(path)
+	if err != nil {
+		return buildpackConfig{}, fmt.Errorf("failed to parse buildpack.toml: %w", err)
+	}
+	defer file.Close()
+
+	_, err = toml.NewDecoder(file).Decode(&config)
+	if err != nil {
+		return buildpackConfig{}, fmt.Errorf("failed to parse buildpack.toml: %w", err)
+	}
+
+	return config, nil
+}
+
+// resolveVersionAlias returns the constraint for the given version if it is a
+// known meta version. Meta versions are matched case-insensitively, as they
+// are by GetVsDbg.sh.
+func (c buildpackConfig) resolveVersionAlias(version string) (string, bool) {
+	for _, alias := range c.Metadata.VersionAliases {
+		if strings.EqualFold(alias.Alias, strings.TrimSpace(version)) {
+			return alias.Constraint, true
+		}
+	}
+
+	return "", false
+}
+
+// supportsTarget reports whether any dependency with the given id can be
+// installed on the target.
+func (c buildpackConfig) supportsTarget(id string, target buildTarget) bool {
+	return slices.ContainsFunc(c.Metadata.Dependencies, func(dependency postal.Dependency) bool {
+		return dependency.ID == id && target.supports(dependency)
+	})
+}
+
+// selectDependency returns the entry of buildpack.toml to install for the
+// resolved dependency on the given target. Of the builds of the resolved
+// release that support the target, it prefers those restricted to the
+// distribution of the target, such as the musl builds for Alpine, and then
+// selects the one with exactly the fourth version component requested by a
+// w.x.y.z version, or otherwise the one with the highest fourth component.
+// The dependency manager orders semantic versions, which ignore the build
+// metadata that records the fourth component, and does not consider the
+// target distribution, so it cannot make this choice itself.
+func (c buildpackConfig) selectDependency(dependency postal.Dependency, version string, target buildTarget) (postal.Dependency, error) {
+	resolved, ok := parseDebuggerVersion(dependency.Version)
+	if !ok {
+		return dependency, nil
+	}
+
+	requested, ok := parseDebuggerVersion(version)
+	exact := ok && requested.hasRevision && requested.sameRelease(resolved)
+
+	var candidates []postal.Dependency
+	for _, candidate := range c.Metadata.Dependencies {
+		if candidate.ID != dependency.ID || !target.supports(candidate) {
+			continue
+		}
+
+		candidateVersion, ok := parseDebuggerVersion(candidate.Version)
+		if !ok || !candidateVersion.sameRelease(resolved) {
+			continue
+		}
+
+		if exact && candidateVersion.compare(requested) != 0 {
+			continue
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	if len(candidates) == 0 {
+		if !target.supports(dependency) {
+			return postal.Dependency{}, fmt.Errorf("no %s %s dependency supports the %s target", dependency.ID, dependency.Version, target)
+		}
+
+		return dependency, nil
+	}
+
+	// Builds that support the target only through their distros are made for
+	// its distribution, whereas builds without distros support any.
+	if slices.ContainsFunc(candidates, func(candidate postal.Dependency) bool { return len(candidate.Distros) > 0 }) {
+		candidates = slices.DeleteFunc(candidates, func(candidate postal.Dependency) bool { return len(candidate.Distros) == 0 })
+	}
+
+	return slices.MaxFunc(candidates, func(a, b postal.Dependency) int {
+		aVersion, _ := parseDebuggerVersion(a.Version)
+		bVersion, _ := parseDebuggerVersion(b.Version)
+		return aVersion.compare(bVersion)
+	}), nil
+}
+
+// availableDependencies returns every version and platform of the dependency
+// with the given id, from the newest version to the oldest.
+func (c buildpackConfig) availableDependencies(id string) []AvailableDependency {
+	var available []AvailableDependency
+	for _, dependency := range c.Metadata.Dependencies {
+		if dependency.ID != id {
+			continue
+		}
+
+		entry := AvailableDependency{Version: dependency.Version, OS: dependency.OS, Arch: dependency.Arch}
+		if !slices.Contains(available, entry) {
+			available = append(available, entry)
+		}
+	}
+
+	slices.SortStableFunc(available, func(a, b AvailableDependency) int {
+		aVersion, aOK := parseDebuggerVersion(a.Version)
+		bVersion, bOK := parseDebuggerVersion(b.Version)
+		if aOK && bOK {
+			if order := bVersion.compare(aVersion); order != 0 {
+				return order
+			}
+		}
+
+		return strings.Compare(a.OS+"/"+a.Arch, b.OS+"/"+b.Arch)
+	})
+
+	return available
+}
@@ -0,0 +1,45 @@
+package vsdbg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BuildpackYMLParser reads the Visual Studio Debugger version constraint from
+// a buildpack.yml file.
+type BuildpackYMLParser struct{}
+
+func NewBuildpackYMLParser() BuildpackYMLParser {
+	return BuildpackYMLParser{}
+}
+
+// ParseVersion returns the value of the vsdbg.version key in the given
+// buildpack.yml file. An empty version is returned if the file does not exist.
+func (p BuildpackYMLParser) ParseVersion(path string) (string, error) {
+	var buildpack struct {
+		VSDBG struct {
+			Version string `yaml:"version"`
+		} `yaml:"vsdbg"`
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("failed to read buildpack.yml: %w", err)
+	}
+	defer file.Close()
+
+	err = yaml.NewDecoder(file).Decode(&buildpack)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to decode buildpack.yml: %w", err)
+	}
+
+	return buildpack.VSDBG.Version, nil
+}
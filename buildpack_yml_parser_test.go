@@ -0,0 +1,102 @@
+package vsdbg_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	vsdbg "github.com/paketo-buildpacks/vsdbg"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBuildpackYMLParser(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+		path       string
+		parser     vsdbg.BuildpackYMLParser
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		path = filepath.Join(workingDir, "buildpack.yml")
+		parser = vsdbg.NewBuildpackYMLParser()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("ParseVersion", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(path, []byte(`---
+vsdbg:
+  version: 17.4.*
+`), 0600)).To(Succeed())
+		})
+
+		it("parses the vsdbg version", func() {
+			version, err := parser.ParseVersion(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("17.4.*"))
+		})
+
+		context("when the file does not declare a vsdbg version", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`---
+dotnet-framework:
+  version: 8.0.*
+`), 0600)).To(Succeed())
+			})
+
+			it("returns an empty version", func() {
+				version, err := parser.ParseVersion(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+			})
+		})
+
+		context("when the file is empty", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, nil, 0600)).To(Succeed())
+			})
+
+			it("returns an empty version", func() {
+				version, err := parser.ParseVersion(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+			})
+		})
+
+		context("when the file does not exist", func() {
+			it.Before(func() {
+				Expect(os.Remove(path)).To(Succeed())
+			})
+
+			it("returns an empty version", func() {
+				version, err := parser.ParseVersion(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(BeEmpty())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the file contents are malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte("%%%"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.ParseVersion(path)
+					Expect(err).To(MatchError(ContainSubstring("failed to decode buildpack.yml")))
+				})
+			})
+		})
+	})
+}
@@ -2,4 +2,101 @@ package vsdbg
 
 const (
 	PlanDependencyVSDBG = "vsdbg"
+
+	// VersionSourceEnvVar is the environment variable that can be used to pin
+	// the version of the Visual Studio Debugger that will be installed.
+	VersionSourceEnvVar = "BP_VSDBG_VERSION"
+
+	// DebugEnabledEnvVar is the environment variable that, when true, causes
+	// the buildpack to require vsdbg at launch for .NET applications.
+	DebugEnabledEnvVar = "BP_DEBUG_ENABLED"
+
+	// ProjectPathEnvVar is the environment variable that sets the directory,
+	// relative to the application source, of the .NET project, as honored by
+	// the other .NET buildpacks.
+	ProjectPathEnvVar = "BP_DOTNET_PROJECT_PATH"
+
+	// BuildpackYMLSource is the name of the file in the application source
+	// that may declare a vsdbg.version constraint.
+	BuildpackYMLSource = "buildpack.yml"
+
+	// DeprecationWarningDaysEnvVar is the environment variable that sets how
+	// many days before its deprecation date a warning is logged for the
+	// selected debugger.
+	DeprecationWarningDaysEnvVar = "BP_VSDBG_DEPRECATION_WARNING_DAYS"
+
+	// FailOnDeprecatedEnvVar is the environment variable that, when true,
+	// fails the build instead of warning about a deprecated debugger.
+	FailOnDeprecatedEnvVar = "BP_VSDBG_FAIL_ON_DEPRECATED"
+
+	// ArchiveEnvVar is the environment variable that sets the path, relative
+	// to the application source, of a debugger archive to install instead of
+	// a dependency from buildpack.toml.
+	ArchiveEnvVar = "BP_VSDBG_ARCHIVE"
+
+	// ArchiveChecksumEnvVar is the environment variable that sets the checksum
+	// the archive set by BP_VSDBG_ARCHIVE is verified against.
+	ArchiveChecksumEnvVar = "BP_VSDBG_ARCHIVE_CHECKSUM"
+
+	// ArchiveBindingType is the type of the service binding that may provide
+	// a debugger archive, as its archive entry, and its checksum entry.
+	ArchiveBindingType = "vsdbg"
+
+	// GLIBCCheckEnvVar is the environment variable that sets whether a
+	// debugger that needs a newer glibc than the stack provides is reported
+	// with a warning, fails the build, or is not checked at all.
+	GLIBCCheckEnvVar = "BP_VSDBG_GLIBC_CHECK"
+
+	// CacheValidationEnvVar is the environment variable that sets whether a
+	// cached layer is checked for the presence of the files in its manifest
+	// or also for their digests before it is reused.
+	CacheValidationEnvVar = "BP_VSDBG_CACHE_VALIDATION"
+
+	// SBOMStrategyEnvVar is the environment variable that sets whether the
+	// SBOM of the layer is generated by scanning the layer, derived from the
+	// metadata of the installed dependency, or both.
+	SBOMStrategyEnvVar = "BP_VSDBG_SBOM_STRATEGY"
+
+	// TrimEnvVar is the environment variable that sets the comma separated
+	// categories of files, locales and symbols, that are removed from the
+	// layer after the debugger is installed.
+	TrimEnvVar = "BP_VSDBG_TRIM"
+
+	// TrimCulturesEnvVar is the environment variable that sets the comma
+	// separated cultures whose localized resources are kept when the others
+	// are removed.
+	TrimCulturesEnvVar = "BP_VSDBG_TRIM_CULTURES"
+
+	// SourceDateEpochEnvVar is the environment variable that sets the
+	// modification time, in seconds since the Unix epoch, that the files in
+	// the layer are given so that their contents are reproducible.
+	SourceDateEpochEnvVar = "SOURCE_DATE_EPOCH"
+
+	// LicenseRef is the SPDX license reference recorded in the SBOM for a
+	// debugger whose dependency does not list its licenses, such as one
+	// installed from an archive. The debugger is distributed under the terms
+	// of its EULA rather than a standard open source license.
+	LicenseRef = "LicenseRef-vsdbg-EULA"
+
+	// HomeEnvVar is the environment variable that later buildpacks and the
+	// launch environment are given the path of the installed debugger in.
+	HomeEnvVar = "VSDBG_HOME"
+
+	// VersionEnvVar is the environment variable that later buildpacks and the
+	// launch environment are given the version of the installed debugger in.
+	VersionEnvVar = "VSDBG_VERSION"
+
+	// VersionLabel, ArchLabel, ChecksumLabel and SourceLabel are the image
+	// labels that describe the debugger installed in a launch layer.
+	VersionLabel  = "io.paketo.vsdbg.version"
+	ArchLabel     = "io.paketo.vsdbg.arch"
+	ChecksumLabel = "io.paketo.vsdbg.checksum"
+	SourceLabel   = "io.paketo.vsdbg.source"
 )
+
+// defaultDeprecationWarningDays is the number of days before its deprecation
+// date that a warning is logged for the selected debugger by default.
+const defaultDeprecationWarningDays = 30
+
+// muslDistros are the distributions that use musl rather than glibc.
+var muslDistros = []string{"alpine"}
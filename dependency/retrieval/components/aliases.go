@@ -0,0 +1,195 @@
+package components
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/paketo-buildpacks/libdependency/versionology"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+)
+
+const versionAliasesKey = "version-aliases"
+
+// VersionAlias maps a meta version, such as vs2022, onto the version
+// constraint of the release it names, as recorded in the version-aliases table
+// of buildpack.toml.
+type VersionAlias struct {
+	Alias      string `json:"alias"`
+	Constraint string `json:"constraint"`
+}
+
+// UnresolvedVersionAliasError is returned when a meta version in
+// buildpack.toml is not satisfied by any dependency of a target.
+type UnresolvedVersionAliasError struct {
+	Alias      string
+	Constraint string
+	Target     string
+}
+
+func (e UnresolvedVersionAliasError) Error() string {
+	return fmt.Sprintf("meta version %q (%s) does not resolve to any dependency for the %s target", e.Alias, e.Constraint, e.Target)
+}
+
+// VersionAliases returns the version-aliases table for the given releases, in
+// the order in which their meta versions are declared. latest always maps onto
+// the newest dependency, while every other meta version maps onto the w.x.y
+// version of the release it names.
+func VersionAliases(releases versionology.VersionFetcherArray) []VersionAlias {
+	aliases := []VersionAlias{{Alias: "latest", Constraint: "*"}}
+	for _, r := range releases {
+		release, ok := r.(VsdbgRelease)
+		if !ok {
+			continue
+		}
+
+		for _, alias := range release.Aliases {
+			if slices.ContainsFunc(aliases, func(a VersionAlias) bool { return a.Alias == alias }) {
+				continue
+			}
+
+			aliases = append(aliases, VersionAlias{
+				Alias:      alias,
+				Constraint: fmt.Sprintf("%d.%d.%d", release.SemVer.Major(), release.SemVer.Minor(), release.SemVer.Patch()),
+			})
+		}
+	}
+
+	return aliases
+}
+
+// GetVersionAliases returns the version-aliases table of buildpack.toml.
+func GetVersionAliases(config cargo.Config) ([]VersionAlias, error) {
+	value, ok := config.Metadata.Unstructured[versionAliasesKey]
+	if !ok {
+		return nil, nil
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var aliases []VersionAlias
+	err = json.Unmarshal(content, &aliases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", versionAliasesKey, err)
+	}
+
+	return aliases, nil
+}
+
+// SetVersionAliases replaces the version-aliases table of buildpack.toml.
+func SetVersionAliases(config cargo.Config, aliases []VersionAlias) cargo.Config {
+	unstructured := map[string]interface{}{}
+	for key, value := range config.Metadata.Unstructured {
+		unstructured[key] = value
+	}
+	unstructured[versionAliasesKey] = aliases
+
+	config.Metadata.Unstructured = unstructured
+	return config
+}
+
+// SetDependencyConstraints replaces the dependency-constraints of
+// buildpack.toml for the dependency with the given id with one constraint per
+// distinct meta version constraint, so that the release of every supported
+// debugger line is kept rather than only the newest release. The patches of
+// constraints that already exist are kept, new constraints keep one patch.
+func SetDependencyConstraints(config cargo.Config, id string, aliases []VersionAlias) cargo.Config {
+	var constraints []cargo.ConfigMetadataDependencyConstraint
+	for _, constraint := range config.Metadata.DependencyConstraints {
+		if constraint.ID != id {
+			constraints = append(constraints, constraint)
+		}
+	}
+
+	for _, alias := range aliases {
+		matches := func(c cargo.ConfigMetadataDependencyConstraint) bool {
+			return c.ID == id && c.Constraint == alias.Constraint
+		}
+
+		if slices.ContainsFunc(constraints, matches) {
+			continue
+		}
+
+		constraint := cargo.ConfigMetadataDependencyConstraint{Constraint: alias.Constraint, ID: id, Patches: 1}
+		if index := slices.IndexFunc(config.Metadata.DependencyConstraints, matches); index >= 0 {
+			constraint = config.Metadata.DependencyConstraints[index]
+		}
+
+		constraints = append(constraints, constraint)
+	}
+
+	config.Metadata.DependencyConstraints = constraints
+	return config
+}
+
+// CheckVersionAliases returns an error for every meta version in
+// buildpack.toml that no dependency with the given id satisfies on one of the
+// targets. Targets without any dependency are skipped, as the buildpack fails
+// detection on them rather than resolving a version.
+func CheckVersionAliases(id string, config cargo.Config) error {
+	aliases, err := GetVersionAliases(config)
+	if err != nil {
+		return err
+	}
+
+	targets := config.Targets
+	if len(targets) == 0 {
+		targets = []cargo.ConfigTarget{{OS: "linux", Arch: "amd64"}}
+	}
+
+	var errs []error
+	for _, target := range targets {
+		var versions []*semver.Version
+		for _, dependency := range config.Metadata.Dependencies {
+			if dependency.ID != id || !matchesTarget(dependency, target) {
+				continue
+			}
+
+			version, err := semver.NewVersion(dependency.Version)
+			if err != nil {
+				return fmt.Errorf("failed to parse version of %s dependency: %w", id, err)
+			}
+			versions = append(versions, version)
+		}
+
+		if len(versions) == 0 {
+			continue
+		}
+
+		for _, alias := range aliases {
+			constraint, err := semver.NewConstraint(alias.Constraint)
+			if err != nil {
+				return fmt.Errorf("failed to parse constraint of meta version %q: %w", alias.Alias, err)
+			}
+
+			if !slices.ContainsFunc(versions, constraint.Check) {
+				errs = append(errs, UnresolvedVersionAliasError{
+					Alias:      alias.Alias,
+					Constraint: alias.Constraint,
+					Target:     fmt.Sprintf("%s/%s", target.OS, target.Arch),
+				})
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// matchesTarget reports whether the dependency can be installed on the
+// target. Dependencies without an OS or architecture are linux/amd64 builds.
+func matchesTarget(dependency cargo.ConfigMetadataDependency, target cargo.ConfigTarget) bool {
+	os, arch := dependency.OS, dependency.Arch
+	if os == "" {
+		os = "linux"
+	}
+	if arch == "" {
+		arch = "amd64"
+	}
+
+	return os == target.OS && arch == target.Arch
+}
@@ -0,0 +1,195 @@
+package components_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/paketo-buildpacks/libdependency/versionology"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/vsdbg/dependency/retrieval/components"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testAliases(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("VersionAliases", func() {
+		it("maps every meta version onto the release it names", func() {
+			aliases := components.VersionAliases(versionology.VersionFetcherArray{
+				components.VsdbgRelease{
+					SemVer:         semver.MustParse("18.7.10521+2"),
+					ReleaseVersion: "18.7.10521.2",
+					Aliases:        []string{"latest", "vs2022", "vs2019"},
+				},
+				components.VsdbgRelease{
+					SemVer:         semver.MustParse("17.4.11017+1"),
+					ReleaseVersion: "17.4.11017.1",
+					Aliases:        []string{"vs2017u5"},
+				},
+				components.VsdbgRelease{
+					SemVer:         semver.MustParse("17.2.10518+1"),
+					ReleaseVersion: "17.2.10518.1",
+				},
+			})
+
+			Expect(aliases).To(Equal([]components.VersionAlias{
+				{Alias: "latest", Constraint: "*"},
+				{Alias: "vs2022", Constraint: "18.7.10521"},
+				{Alias: "vs2019", Constraint: "18.7.10521"},
+				{Alias: "vs2017u5", Constraint: "17.4.11017"},
+			}))
+		})
+	})
+
+	context("SetVersionAliases", func() {
+		it("replaces the version-aliases table and keeps the rest of the metadata", func() {
+			var config cargo.Config
+			err := cargo.DecodeConfig(strings.NewReader(`
+api = "0.8"
+
+[metadata]
+  some-key = "some-value"
+
+  [[metadata.version-aliases]]
+    alias = "vs2019"
+    constraint = "16.9.20122"
+`), &config)
+			Expect(err).NotTo(HaveOccurred())
+
+			config = components.SetVersionAliases(config, []components.VersionAlias{
+				{Alias: "latest", Constraint: "*"},
+				{Alias: "vs2019", Constraint: "18.7.10521"},
+			})
+
+			aliases, err := components.GetVersionAliases(config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(aliases).To(Equal([]components.VersionAlias{
+				{Alias: "latest", Constraint: "*"},
+				{Alias: "vs2019", Constraint: "18.7.10521"},
+			}))
+
+			buffer := bytes.NewBuffer(nil)
+			Expect(cargo.EncodeConfig(buffer, config)).To(Succeed())
+			Expect(buffer.String()).To(ContainSubstring(`some-key = "some-value"`))
+			Expect(buffer.String()).NotTo(ContainSubstring("16.9.20122"))
+		})
+	})
+
+	context("SetDependencyConstraints", func() {
+		it("adds a constraint for the release of every meta version", func() {
+			config := cargo.Config{
+				Metadata: cargo.ConfigMetadata{
+					DependencyConstraints: []cargo.ConfigMetadataDependencyConstraint{
+						{Constraint: "*", ID: "vsdbg", Patches: 2},
+						{Constraint: "16.9.20122", ID: "vsdbg", Patches: 1},
+						{Constraint: "1.*", ID: "other", Patches: 3},
+					},
+				},
+			}
+
+			config = components.SetDependencyConstraints(config, "vsdbg", []components.VersionAlias{
+				{Alias: "latest", Constraint: "*"},
+				{Alias: "vs2022", Constraint: "18.7.10521"},
+				{Alias: "vs2019", Constraint: "18.7.10521"},
+				{Alias: "vs2017u5", Constraint: "17.4.11017"},
+			})
+
+			Expect(config.Metadata.DependencyConstraints).To(Equal([]cargo.ConfigMetadataDependencyConstraint{
+				{Constraint: "1.*", ID: "other", Patches: 3},
+				{Constraint: "*", ID: "vsdbg", Patches: 2},
+				{Constraint: "18.7.10521", ID: "vsdbg", Patches: 1},
+				{Constraint: "17.4.11017", ID: "vsdbg", Patches: 1},
+			}))
+		})
+	})
+
+	context("CheckVersionAliases", func() {
+		var config cargo.Config
+
+		it.Before(func() {
+			err := cargo.DecodeConfig(strings.NewReader(`
+api = "0.8"
+
+[metadata]
+
+  [[metadata.dependencies]]
+    arch = "amd64"
+    id = "vsdbg"
+    os = "linux"
+    version = "18.7.10521+2"
+
+  [[metadata.dependencies]]
+    arch = "arm64"
+    id = "vsdbg"
+    os = "linux"
+    version = "18.7.10521+2"
+
+  [[metadata.dependencies]]
+    arch = "amd64"
+    id = "vsdbg"
+    os = "linux"
+    version = "17.4.11017+1"
+
+  [[metadata.version-aliases]]
+    alias = "latest"
+    constraint = "*"
+
+  [[metadata.version-aliases]]
+    alias = "vs2022"
+    constraint = "18.7.10521"
+
+[[targets]]
+  arch = "amd64"
+  os = "linux"
+
+[[targets]]
+  arch = "arm64"
+  os = "linux"
+
+[[targets]]
+  arch = "arm"
+  os = "linux"
+`), &config)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("skips targets without dependencies", func() {
+			Expect(components.CheckVersionAliases("vsdbg", config)).To(Succeed())
+		})
+
+		context("when a meta version does not resolve on a target", func() {
+			it.Before(func() {
+				config = components.SetVersionAliases(config, []components.VersionAlias{
+					{Alias: "latest", Constraint: "*"},
+					{Alias: "vs2017u5", Constraint: "17.4.11017"},
+					{Alias: "vs2019", Constraint: "16.9.20122"},
+				})
+			})
+
+			it("returns an error for every target", func() {
+				err := components.CheckVersionAliases("vsdbg", config)
+				Expect(err).To(MatchError(ContainSubstring(`meta version "vs2017u5" (17.4.11017) does not resolve to any dependency for the linux/arm64 target`)))
+				Expect(err).To(MatchError(ContainSubstring(`meta version "vs2019" (16.9.20122) does not resolve to any dependency for the linux/amd64 target`)))
+				Expect(err).To(MatchError(ContainSubstring(`meta version "vs2019" (16.9.20122) does not resolve to any dependency for the linux/arm64 target`)))
+				Expect(err).NotTo(MatchError(ContainSubstring(`meta version "vs2017u5" (17.4.11017) does not resolve to any dependency for the linux/amd64 target`)))
+			})
+		})
+
+		context("when a meta version has an invalid constraint", func() {
+			it.Before(func() {
+				config = components.SetVersionAliases(config, []components.VersionAlias{
+					{Alias: "vs2022", Constraint: "not-a-constraint"},
+				})
+			})
+
+			it("returns an error", func() {
+				err := components.CheckVersionAliases("vsdbg", config)
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse constraint of meta version "vs2022"`)))
+			})
+		})
+	})
+}
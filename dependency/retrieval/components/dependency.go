@@ -2,18 +2,73 @@ package components
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/paketo-buildpacks/libdependency/retrieve"
 	"github.com/paketo-buildpacks/libdependency/versionology"
 	"github.com/paketo-buildpacks/packit/v2/cargo"
 )
 
+// HTTPClient is the interface of the client used to download the debugger
+// tarballs. It is satisfied by *http.Client.
+type HTTPClient interface {
+	Do(request *http.Request) (*http.Response, error)
+}
+
+// ContentLengthError is returned when the number of bytes downloaded does not
+// match the Content-Length announced by the server, as happens when a CDN
+// truncates a response.
+type ContentLengthError struct {
+	URL      string
+	Expected int64
+	Received int64
+}
+
+func (e ContentLengthError) Error() string {
+	return fmt.Sprintf("received %d bytes from %s: expected %d bytes", e.Received, e.URL, e.Expected)
+}
+
+// SizeLimitError is returned when a download exceeds the maximum size that
+// the generator is willing to hash.
+type SizeLimitError struct {
+	URL   string
+	Limit int64
+}
+
+func (e SizeLimitError) Error() string {
+	return fmt.Sprintf("download from %s exceeds the size limit of %d bytes", e.URL, e.Limit)
+}
+
 type Generator struct {
 	UrlFormatter func(version string, os string, arch string) string
+
+	// Client performs the download requests.
+	Client HTTPClient
+
+	// MaxAttempts is the number of times a download is attempted when it
+	// fails with a transient error or a 5xx status code.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry. It doubles with every
+	// subsequent retry.
+	Backoff time.Duration
+
+	// MaxSize is the maximum number of bytes that are downloaded.
+	MaxSize int64
+
+	// LicenseRef is the SPDX license reference recorded for the debugger.
+	// When a tarball contains a license or EULA file, the digest of its text
+	// is appended to the reference.
+	LicenseRef string
 }
 
 func NewGenerator() Generator {
@@ -21,6 +76,19 @@ func NewGenerator() Generator {
 		UrlFormatter: func(version string, os string, arch string) string {
 			return fmt.Sprintf("https://vsdebugger-cyg0dxb6czfafzaz.b01.azurefd.net/vsdbg-%s/vsdbg-%s-%s.tar.gz", version, os, arch)
 		},
+		Client: &http.Client{
+			Timeout: 10 * time.Minute,
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				DialContext:           (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+				TLSHandshakeTimeout:   30 * time.Second,
+				ResponseHeaderTimeout: time.Minute,
+			},
+		},
+		MaxAttempts: 5,
+		Backoff:     2 * time.Second,
+		MaxSize:     1 << 30,
+		LicenseRef:  DefaultLicenseRef,
 	}
 }
 
@@ -31,55 +99,251 @@ func (g Generator) WithFakeUrl(url string) Generator {
 	return g
 }
 
+func (g Generator) WithClient(client HTTPClient) Generator {
+	g.Client = client
+	return g
+}
+
+func (g Generator) WithRetries(maxAttempts int, backoff time.Duration) Generator {
+	g.MaxAttempts = maxAttempts
+	g.Backoff = backoff
+	return g
+}
+
+func (g Generator) WithMaxSize(maxSize int64) Generator {
+	g.MaxSize = maxSize
+	return g
+}
+
+func (g Generator) WithLicenseRef(licenseRef string) Generator {
+	g.LicenseRef = licenseRef
+	return g
+}
+
+// MuslDistro is the distribution that dependencies built against musl libc
+// are restricted to, distinguishing them from the glibc builds that support
+// every stack.
+const MuslDistro = "alpine"
+
+// runtimeArchs maps the architectures of buildpack targets onto the
+// architecture component of the .NET runtime identifiers used in the names
+// of the debugger tarballs.
+var runtimeArchs = map[string]string{
+	"amd64": "x64",
+	"arm64": "arm64",
+	"arm":   "arm",
+}
+
+// muslArchs are the architectures for which musl builds of the debugger are
+// published.
+var muslArchs = []string{"amd64", "arm64"}
+
 func (g Generator) GenerateMetadata(version versionology.VersionFetcher, platform retrieve.Platform) ([]versionology.Dependency, error) {
 	vsdbgRelease := version.(VsdbgRelease)
 
-	arch := platform.Arch
-	if platform.Arch == "amd64" {
-		arch = "x64"
+	arch, ok := runtimeArchs[platform.Arch]
+	if !ok {
+		return nil, fmt.Errorf("unsupported architecture %q", platform.Arch)
 	}
 
-	url := g.UrlFormatter(strings.Join(vsdbgRelease.SplitVersion, "-"), platform.OS, arch)
-
-	response, err := http.Get(url)
+	glibc, err := g.generateDependency(vsdbgRelease, platform, platform.OS, arch, "")
 	if err != nil {
 		return nil, err
 	}
-	defer response.Body.Close()
 
-	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
-		return nil, fmt.Errorf("received a non 200 status code from %s: status code %d received", url, response.StatusCode)
+	dependencies := []versionology.Dependency{glibc}
+
+	if platform.OS == "linux" && slices.Contains(muslArchs, platform.Arch) {
+		musl, err := g.generateDependency(vsdbgRelease, platform, "linux-musl", arch, MuslDistro)
+		if err != nil {
+			return nil, err
+		}
+
+		dependencies = append(dependencies, musl)
 	}
 
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, response.Body); err != nil {
-		return nil, err
+	return dependencies, nil
+}
+
+// generateDependency returns the dependency for the given tarball. A
+// dependency that is restricted to a distribution, such as a musl build, is
+// matched on the distros of the target rather than on the stack.
+func (g Generator) generateDependency(vsdbgRelease VsdbgRelease, platform retrieve.Platform, runtimeOS, arch, distro string) (versionology.Dependency, error) {
+	url := g.UrlFormatter(strings.Join(vsdbgRelease.SplitVersion, "-"), runtimeOS, arch)
+
+	tarball, err := g.fetch(url)
+	if err != nil {
+		return versionology.Dependency{}, err
 	}
+	hash := tarball.SHA256
 
 	cpe := fmt.Sprintf("cpe:2.3:a:microsoft:vsdbg:%s:*:*:*:*:*:*:*", vsdbgRelease.ReleaseVersion)
-	hash := fmt.Sprintf("%x", hasher.Sum(nil))
 	purl := retrieve.GeneratePURL("vsdbg", vsdbgRelease.ReleaseVersion, hash, url)
 
+	// VSDBG does not use a standard open source license, so its EULA is
+	// recorded as a license reference.
+	var licenses []interface{}
+	if ref := licenseRef(g.LicenseRef, tarball.LicenseText); ref != "" {
+		licenses = []interface{}{ref}
+	}
+
 	metadataDependency := cargo.ConfigMetadataDependency{
 		ID:             "vsdbg",
 		Name:           "Visual Studio Debugger",
 		Version:        vsdbgRelease.SemVer.String(),
-		Stacks:         []string{"*"},
 		URI:            url,
 		Checksum:       fmt.Sprintf("sha256:%s", hash),
 		Source:         url,
 		SourceChecksum: fmt.Sprintf("sha256:%s", hash),
 		CPE:            cpe,
 		PURL:           purl,
-		Licenses:       nil, // VSDBG does not use a standard open source license
+		Licenses:       licenses,
 		OS:             platform.OS,
 		Arch:           platform.Arch,
 	}
 
-	dependency, err := versionology.NewDependency(metadataDependency, "*")
+	target := "*"
+	if distro == "" {
+		metadataDependency.Stacks = []string{"*"}
+	} else {
+		metadataDependency.Distros = []cargo.ConfigDistro{{Name: distro}}
+		target = distro
+	}
+
+	return versionology.NewDependency(metadataDependency, target)
+}
+
+// tarball is what is learned from downloading a debugger tarball.
+type tarball struct {
+	// SHA256 is the hex encoded SHA256 of the tarball.
+	SHA256 string
+
+	// LicenseText is the text of the license or EULA file in the tarball, if
+	// it has one.
+	LicenseText []byte
+}
+
+// fetch downloads the tarball at the given URL. Downloads that fail with a
+// transient error, a 5xx status code or a truncated body are retried with an
+// exponential backoff.
+func (g Generator) fetch(url string) (tarball, error) {
+	attempts := max(g.MaxAttempts, 1)
+	backoff := g.Backoff
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		var (
+			result    tarball
+			retryable bool
+		)
+		result, retryable, err = g.download(url)
+		if err == nil {
+			return result, nil
+		}
+
+		if !retryable {
+			return tarball{}, err
+		}
+	}
+
+	return tarball{}, fmt.Errorf("failed to download %s after %d attempts: %w", url, attempts, err)
+}
+
+// download performs a single attempt at hashing the contents of the given URL,
+// reading the license text from the tarball as it is hashed, and reports
+// whether a failure may be resolved by retrying.
+func (g Generator) download(url string) (tarball, bool, error) {
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return tarball{}, false, err
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return tarball{}, isTransient(err), err
+	}
+	defer response.Body.Close()
+
+	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
+		return tarball{}, response.StatusCode >= 500, fmt.Errorf("received a non 200 status code from %s: status code %d received", url, response.StatusCode)
+	}
+
+	body := io.Reader(response.Body)
+	if g.MaxSize > 0 {
+		if response.ContentLength > g.MaxSize {
+			return tarball{}, false, SizeLimitError{URL: url, Limit: g.MaxSize}
+		}
+		body = io.LimitReader(response.Body, g.MaxSize+1)
+	}
+
+	// The body is hashed as the license text is read from it, and the part of
+	// the body after the license file is drained into the hash, so that the
+	// tarball is only downloaded once. Errors reading the body are recorded by
+	// the counting reader, as the tarball reader does not report them.
+	counter := &countingReader{reader: body}
+	hasher := sha256.New()
+	tee := io.TeeReader(counter, hasher)
+
+	licenseText := findLicenseText(tee)
+	_, _ = io.Copy(io.Discard, tee)
+	if counter.err != nil {
+		return tarball{}, isTransient(counter.err), counter.err
+	}
+
+	if g.MaxSize > 0 && counter.count > g.MaxSize {
+		return tarball{}, false, SizeLimitError{URL: url, Limit: g.MaxSize}
+	}
+
+	if response.ContentLength >= 0 && counter.count != response.ContentLength {
+		return tarball{}, true, ContentLengthError{URL: url, Expected: response.ContentLength, Received: counter.count}
+	}
+
+	return tarball{
+		SHA256:      hex.EncodeToString(hasher.Sum(nil)),
+		LicenseText: licenseText,
+	}, false, nil
+}
+
+// countingReader counts the bytes read from a reader and records the first
+// error other than io.EOF that the reader returns.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+	err    error
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+
+	return n, err
+}
+
+// isTransient reports whether a request error is caused by the network rather
+// than by the request itself, such as an invalid URL.
+func isTransient(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
 	}
 
-	return []versionology.Dependency{dependency}, nil
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
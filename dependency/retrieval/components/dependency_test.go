@@ -4,10 +4,15 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/paketo-buildpacks/libdependency/retrieve"
@@ -100,9 +105,12 @@ func testDependency(t *testing.T, context spec.G, it spec.S) {
 			}, retrieve.Platform{OS: "linux", Arch: "amd64"})
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(dependencies).To(HaveLen(1))
+			Expect(dependencies).To(HaveLen(2))
 			dependency := dependencies[0]
 
+			licenseSum := sha256.Sum256([]byte(lFile))
+			licenseRef := fmt.Sprintf("LicenseRef-vsdbg-EULA-%s", hex.EncodeToString(licenseSum[:])[:12])
+
 			Expect(dependency).To(BeEquivalentTo(
 				versionology.Dependency{
 					ConfigMetadataDependency: cargo.ConfigMetadataDependency{
@@ -110,7 +118,7 @@ func testDependency(t *testing.T, context spec.G, it spec.S) {
 						CPE:             "cpe:2.3:a:microsoft:vsdbg:17.4.11017.1:*:*:*:*:*:*:*",
 						PURL:            fmt.Sprintf("pkg:generic/vsdbg@17.4.11017.1?checksum=5a95bcffa592dcc7689ef5b4d993da3ca805b3c58d1710da8effeedbda87d471&download_url=%s", server.URL),
 						ID:              "vsdbg",
-						Licenses:        nil,
+						Licenses:        []interface{}{licenseRef},
 						Name:            "Visual Studio Debugger",
 						SHA256:          "",
 						Source:          server.URL,
@@ -126,9 +134,261 @@ func testDependency(t *testing.T, context spec.G, it spec.S) {
 					SemverVersion: semver.MustParse("17.4.11017-1"),
 					Target:        "*",
 				}))
+
+			musl := dependencies[1]
+			Expect(musl.ID).To(Equal("vsdbg"))
+			Expect(musl.ConfigMetadataDependency.Version).To(Equal("17.4.11017-1"))
+			Expect(musl.OS).To(Equal("linux"))
+			Expect(musl.Arch).To(Equal("amd64"))
+			Expect(musl.Stacks).To(BeEmpty())
+			Expect(musl.Distros).To(Equal([]cargo.ConfigDistro{{Name: "alpine"}}))
+			Expect(musl.Target).To(Equal("alpine"))
+		})
+
+		it("requests the glibc and musl builds of the release", func() {
+			var urls []string
+			generator := components.NewGenerator()
+			formatter := generator.UrlFormatter
+			generator.UrlFormatter = func(version, os, arch string) string {
+				urls = append(urls, formatter(version, os, arch))
+				return server.URL
+			}
+
+			_, err := generator.GenerateMetadata(components.VsdbgRelease{
+				SemVer:         semver.MustParse("17.4.11017-1"),
+				ReleaseVersion: "17.4.11017.1",
+				SplitVersion:   []string{"17", "4", "11017", "1"},
+			}, retrieve.Platform{OS: "linux", Arch: "arm64"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(urls).To(Equal([]string{
+				"https://vsdebugger-cyg0dxb6czfafzaz.b01.azurefd.net/vsdbg-17-4-11017-1/vsdbg-linux-arm64.tar.gz",
+				"https://vsdebugger-cyg0dxb6czfafzaz.b01.azurefd.net/vsdbg-17-4-11017-1/vsdbg-linux-musl-arm64.tar.gz",
+			}))
+		})
+
+		it("maps the arm architecture and skips the musl build", func() {
+			var urls []string
+			generator := components.NewGenerator()
+			formatter := generator.UrlFormatter
+			generator.UrlFormatter = func(version, os, arch string) string {
+				urls = append(urls, formatter(version, os, arch))
+				return server.URL
+			}
+
+			dependencies, err := generator.GenerateMetadata(components.VsdbgRelease{
+				SemVer:         semver.MustParse("17.4.11017-1"),
+				ReleaseVersion: "17.4.11017.1",
+				SplitVersion:   []string{"17", "4", "11017", "1"},
+			}, retrieve.Platform{OS: "linux", Arch: "arm"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dependencies).To(HaveLen(1))
+			Expect(dependencies[0].Arch).To(Equal("arm"))
+			Expect(dependencies[0].Stacks).To(Equal([]string{"*"}))
+
+			Expect(urls).To(Equal([]string{
+				"https://vsdebugger-cyg0dxb6czfafzaz.b01.azurefd.net/vsdbg-17-4-11017-1/vsdbg-linux-arm.tar.gz",
+			}))
+		})
+
+		context("when the download fails with a 5xx status code", func() {
+			var (
+				requests int
+				flaky    *httptest.Server
+			)
+
+			it.Before(func() {
+				requests = 0
+				flaky = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					requests++
+					if requests < 3 {
+						w.WriteHeader(http.StatusBadGateway)
+						return
+					}
+
+					fmt.Fprint(w, "some-content")
+				}))
+			})
+
+			it.After(func() {
+				flaky.Close()
+			})
+
+			it("retries the download", func() {
+				generator := components.NewGenerator().WithFakeUrl(flaky.URL).WithRetries(3, time.Millisecond)
+				dependencies, err := generator.GenerateMetadata(components.VsdbgRelease{
+					SemVer:         semver.MustParse("17.4.11017-1"),
+					ReleaseVersion: "17.4.11017.1",
+					SplitVersion:   []string{"17", "4", "11017", "1"},
+				}, retrieve.Platform{OS: "windows", Arch: "amd64"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(requests).To(Equal(3))
+				Expect(dependencies).To(HaveLen(1))
+				Expect(dependencies[0].Checksum).To(Equal("sha256:0a8cac771ca188eacc57e2c96c31f5611925c5ecedccb16b8c236d6c0d325112"))
+			})
+		})
+
+		context("when the tarball does not contain a license file", func() {
+			it("records the license reference of the EULA", func() {
+				generator := components.NewGenerator().WithFakeUrl(server.URL).WithClient(&recordingClient{})
+				dependencies, err := generator.GenerateMetadata(components.VsdbgRelease{
+					SemVer:         semver.MustParse("17.4.11017-1"),
+					ReleaseVersion: "17.4.11017.1",
+					SplitVersion:   []string{"17", "4", "11017", "1"},
+				}, retrieve.Platform{OS: "windows", Arch: "amd64"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencies).To(HaveLen(1))
+				Expect(dependencies[0].Licenses).To(Equal([]interface{}{"LicenseRef-vsdbg-EULA"}))
+			})
+		})
+
+		context("when a license reference is given", func() {
+			it("records the given license reference", func() {
+				generator := components.NewGenerator().WithFakeUrl(server.URL).WithClient(&recordingClient{}).WithLicenseRef("LicenseRef-some-eula")
+				dependencies, err := generator.GenerateMetadata(components.VsdbgRelease{
+					SemVer:         semver.MustParse("17.4.11017-1"),
+					ReleaseVersion: "17.4.11017.1",
+					SplitVersion:   []string{"17", "4", "11017", "1"},
+				}, retrieve.Platform{OS: "windows", Arch: "amd64"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencies).To(HaveLen(1))
+				Expect(dependencies[0].Licenses).To(Equal([]interface{}{"LicenseRef-some-eula"}))
+			})
+		})
+
+		context("when the license reference is empty", func() {
+			it("does not record a license", func() {
+				generator := components.NewGenerator().WithFakeUrl(server.URL).WithLicenseRef("")
+				dependencies, err := generator.GenerateMetadata(components.VsdbgRelease{
+					SemVer:         semver.MustParse("17.4.11017-1"),
+					ReleaseVersion: "17.4.11017.1",
+					SplitVersion:   []string{"17", "4", "11017", "1"},
+				}, retrieve.Platform{OS: "windows", Arch: "amd64"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencies).To(HaveLen(1))
+				Expect(dependencies[0].Licenses).To(BeNil())
+			})
+		})
+
+		it("uses the given HTTP client", func() {
+			client := &recordingClient{}
+			generator := components.NewGenerator().WithFakeUrl(server.URL).WithClient(client)
+			_, err := generator.GenerateMetadata(components.VsdbgRelease{
+				SemVer:         semver.MustParse("17.4.11017-1"),
+				ReleaseVersion: "17.4.11017.1",
+				SplitVersion:   []string{"17", "4", "11017", "1"},
+			}, retrieve.Platform{OS: "windows", Arch: "amd64"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.urls).To(Equal([]string{server.URL}))
 		})
 
 		context("failure cases", func() {
+			context("when the download keeps failing with a 5xx status code", func() {
+				var (
+					requests int
+					broken   *httptest.Server
+				)
+
+				it.Before(func() {
+					requests = 0
+					broken = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						requests++
+						w.WriteHeader(http.StatusServiceUnavailable)
+					}))
+				})
+
+				it.After(func() {
+					broken.Close()
+				})
+
+				it("returns an error after the last attempt", func() {
+					generator := components.NewGenerator().WithFakeUrl(broken.URL).WithRetries(3, time.Millisecond)
+					_, err := generator.GenerateMetadata(components.VsdbgRelease{
+						SemVer:         semver.MustParse("17.4.11017-1"),
+						ReleaseVersion: "17.4.11017.1",
+						SplitVersion:   []string{"17", "4", "11017", "1"},
+					}, retrieve.Platform{OS: "linux", Arch: "amd64"})
+					Expect(err).To(MatchError(fmt.Sprintf("failed to download %[1]s after 3 attempts: received a non 200 status code from %[1]s: status code 503 received", broken.URL)))
+					Expect(requests).To(Equal(3))
+				})
+			})
+
+			context("when the response is truncated", func() {
+				var (
+					requests  int
+					truncated *httptest.Server
+				)
+
+				it.Before(func() {
+					requests = 0
+					truncated = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						requests++
+						w.Header().Set("Content-Length", "100")
+						w.WriteHeader(http.StatusOK)
+						fmt.Fprint(w, "some-content")
+					}))
+				})
+
+				it.After(func() {
+					truncated.Close()
+				})
+
+				it("retries and then returns an error", func() {
+					generator := components.NewGenerator().WithFakeUrl(truncated.URL).WithRetries(2, time.Millisecond)
+					_, err := generator.GenerateMetadata(components.VsdbgRelease{
+						SemVer:         semver.MustParse("17.4.11017-1"),
+						ReleaseVersion: "17.4.11017.1",
+						SplitVersion:   []string{"17", "4", "11017", "1"},
+					}, retrieve.Platform{OS: "linux", Arch: "amd64"})
+					Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("failed to download %s after 2 attempts", truncated.URL))))
+					Expect(requests).To(Equal(2))
+				})
+			})
+
+			context("when the body does not match the Content-Length", func() {
+				it("returns a ContentLengthError", func() {
+					client := &recordingClient{contentLength: 100}
+					generator := components.NewGenerator().WithFakeUrl(server.URL).WithClient(client).WithRetries(2, time.Millisecond)
+					_, err := generator.GenerateMetadata(components.VsdbgRelease{
+						SemVer:         semver.MustParse("17.4.11017-1"),
+						ReleaseVersion: "17.4.11017.1",
+						SplitVersion:   []string{"17", "4", "11017", "1"},
+					}, retrieve.Platform{OS: "linux", Arch: "amd64"})
+					Expect(err).To(MatchError(components.ContentLengthError{URL: server.URL, Expected: 100, Received: 12}))
+					Expect(client.urls).To(HaveLen(2))
+				})
+			})
+
+			context("when the download exceeds the size limit", func() {
+				it("returns a SizeLimitError", func() {
+					generator := components.NewGenerator().WithFakeUrl(server.URL).WithMaxSize(10)
+					_, err := generator.GenerateMetadata(components.VsdbgRelease{
+						SemVer:         semver.MustParse("17.4.11017-1"),
+						ReleaseVersion: "17.4.11017.1",
+						SplitVersion:   []string{"17", "4", "11017", "1"},
+					}, retrieve.Platform{OS: "linux", Arch: "amd64"})
+					Expect(err).To(MatchError(components.SizeLimitError{URL: server.URL, Limit: 10}))
+				})
+			})
+
+			context("when the architecture is not supported", func() {
+				it("returns an error", func() {
+					generator := components.NewGenerator().WithFakeUrl(server.URL)
+					_, err := generator.GenerateMetadata(components.VsdbgRelease{
+						SemVer:         semver.MustParse("17.4.11017-1"),
+						ReleaseVersion: "17.4.11017.1",
+						SplitVersion:   []string{"17", "4", "11017", "1"},
+					}, retrieve.Platform{OS: "linux", Arch: "ppc64le"})
+					Expect(err).To(MatchError(`unsupported architecture "ppc64le"`))
+				})
+			})
+
 			context("when the release get fails", func() {
 				it("returns an error", func() {
 					generator := components.NewGenerator().WithFakeUrl("not a valid url")
@@ -155,3 +415,27 @@ func testDependency(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 }
+
+// recordingClient is an HTTPClient that records the requested URLs and
+// responds with a fixed body, announcing the given Content-Length when it is
+// set.
+type recordingClient struct {
+	urls          []string
+	contentLength int64
+}
+
+func (c *recordingClient) Do(request *http.Request) (*http.Response, error) {
+	c.urls = append(c.urls, request.URL.String())
+
+	body := "some-content"
+	contentLength := int64(len(body))
+	if c.contentLength != 0 {
+		contentLength = c.contentLength
+	}
+
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		ContentLength: contentLength,
+		Body:          io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
@@ -0,0 +1,94 @@
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/paketo-buildpacks/libdependency/retrieve"
+	"github.com/paketo-buildpacks/libdependency/versionology"
+)
+
+// FeedFetcher discovers debugger releases from a JSON feed rather than from
+// GetVsDbg.sh. The feed is expected to have the following shape:
+//
+//	{
+//	  "releases": [
+//	    { "version": "17.4.11017.1", "aliases": ["latest", "vs2022"] }
+//	  ]
+//	}
+type FeedFetcher struct {
+	feedURL string
+}
+
+func NewFeedFetcher() FeedFetcher {
+	return FeedFetcher{}
+}
+
+func (f FeedFetcher) WithFeedURL(url string) FeedFetcher {
+	f.feedURL = url
+	return f
+}
+
+func (f FeedFetcher) GetVersions() (versionology.VersionFetcherArray, error) {
+	if f.feedURL == "" {
+		return nil, fmt.Errorf("no release feed URL configured")
+	}
+
+	response, err := http.Get(f.feedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if !(response.StatusCode >= 200 && response.StatusCode < 300) {
+		return nil, fmt.Errorf("received a non 200 status code from %s: status code %d received", f.feedURL, response.StatusCode)
+	}
+
+	var feed struct {
+		Releases []struct {
+			Version string   `json:"version"`
+			Aliases []string `json:"aliases"`
+		} `json:"releases"`
+	}
+
+	err = json.NewDecoder(response.Body).Decode(&feed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode release feed: %w", err)
+	}
+
+	var releases []versionology.VersionFetcher
+	for _, r := range feed.Releases {
+		releases, err = addRelease(releases, r.Version, r.Aliases...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return releases, nil
+}
+
+// MergeVersions returns a function that combines the releases of each of the
+// given version sources. Releases that share a version are merged into one
+// release carrying the aliases from every source.
+func MergeVersions(sources ...retrieve.GetAllVersionsFunc) retrieve.GetAllVersionsFunc {
+	return func() (versionology.VersionFetcherArray, error) {
+		var releases []versionology.VersionFetcher
+		for _, getVersions := range sources {
+			versions, err := getVersions()
+			if err != nil {
+				return nil, err
+			}
+
+			for _, v := range versions {
+				release := v.(VsdbgRelease)
+				releases, err = addRelease(releases, release.ReleaseVersion, release.Aliases...)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return releases, nil
+	}
+}
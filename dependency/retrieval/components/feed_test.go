@@ -0,0 +1,166 @@
+package components_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/paketo-buildpacks/libdependency/versionology"
+	"github.com/paketo-buildpacks/vsdbg/dependency/retrieval/components"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testFeed(t *testing.T, context spec.G, it spec.S) {
+
+	var (
+		Expect = NewWithT(t).Expect
+
+		server *httptest.Server
+	)
+
+	it.Before(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method == http.MethodHead {
+				http.Error(w, "NotFound", http.StatusNotFound)
+				return
+			}
+
+			switch req.URL.Path {
+			case "/feed.json":
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, `{
+  "releases": [
+    { "version": "18.7.10521.2", "aliases": ["latest", "vs2022"] },
+    { "version": "17.4.11017.1" }
+  ]
+}`)
+
+			case "/non-200":
+				w.WriteHeader(http.StatusTeapot)
+
+			case "/malformed.json":
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, `{ "releases": `)
+
+			case "/wrong-version-format.json":
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprintln(w, `{ "releases": [ { "version": "18.7" } ] }`)
+
+			default:
+				t.Fatalf("unknown path: %s", req.URL.Path)
+			}
+		}))
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("FeedFetcher.GetVersions", func() {
+		it("fetches the releases listed in the feed", func() {
+			releases, err := components.NewFeedFetcher().WithFeedURL(fmt.Sprintf("%s/feed.json", server.URL)).GetVersions()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(releases).To(BeEquivalentTo([]versionology.VersionFetcher{
+				components.VsdbgRelease{
+					SemVer:         semver.MustParse("18.7.10521+2"),
+					ReleaseVersion: "18.7.10521.2",
+					SplitVersion:   []string{"18", "7", "10521", "2"},
+					Aliases:        []string{"latest", "vs2022"},
+				},
+				components.VsdbgRelease{
+					SemVer:         semver.MustParse("17.4.11017+1"),
+					ReleaseVersion: "17.4.11017.1",
+					SplitVersion:   []string{"17", "4", "11017", "1"},
+				},
+			}))
+		})
+
+		context("failure cases", func() {
+			context("when no feed URL is configured", func() {
+				it("returns an error", func() {
+					_, err := components.NewFeedFetcher().GetVersions()
+					Expect(err).To(MatchError("no release feed URL configured"))
+				})
+			})
+
+			context("when the feed get fails", func() {
+				it("returns an error", func() {
+					_, err := components.NewFeedFetcher().WithFeedURL("not a valid URL").GetVersions()
+					Expect(err).To(MatchError(ContainSubstring("unsupported protocol scheme")))
+				})
+			})
+
+			context("when the feed get returns non 200 code", func() {
+				it("returns an error", func() {
+					url := fmt.Sprintf("%s/non-200", server.URL)
+					_, err := components.NewFeedFetcher().WithFeedURL(url).GetVersions()
+					Expect(err).To(MatchError(fmt.Sprintf("received a non 200 status code from %s: status code 418 received", url)))
+				})
+			})
+
+			context("when the feed cannot be decoded", func() {
+				it("returns an error", func() {
+					_, err := components.NewFeedFetcher().WithFeedURL(fmt.Sprintf("%s/malformed.json", server.URL)).GetVersions()
+					Expect(err).To(MatchError(ContainSubstring("failed to decode release feed")))
+				})
+			})
+
+			context("when a version is not w.x.y.z format", func() {
+				it("returns an error", func() {
+					_, err := components.NewFeedFetcher().WithFeedURL(fmt.Sprintf("%s/wrong-version-format.json", server.URL)).GetVersions()
+					Expect(err).To(MatchError(components.VersionFormatError{Version: "18.7"}))
+				})
+			})
+		})
+	})
+
+	context("MergeVersions", func() {
+		it("merges releases that share a version", func() {
+			getVersions := components.MergeVersions(
+				components.NewFeedFetcher().WithFeedURL(fmt.Sprintf("%s/feed.json", server.URL)).GetVersions,
+				func() (versionology.VersionFetcherArray, error) {
+					return versionology.VersionFetcherArray{
+						components.VsdbgRelease{
+							SemVer:         semver.MustParse("17.4.11017+1"),
+							ReleaseVersion: "17.4.11017.1",
+							SplitVersion:   []string{"17", "4", "11017", "1"},
+							Aliases:        []string{"vs2019"},
+						},
+					}, nil
+				},
+			)
+
+			releases, err := getVersions()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(releases).To(BeEquivalentTo([]versionology.VersionFetcher{
+				components.VsdbgRelease{
+					SemVer:         semver.MustParse("18.7.10521+2"),
+					ReleaseVersion: "18.7.10521.2",
+					SplitVersion:   []string{"18", "7", "10521", "2"},
+					Aliases:        []string{"latest", "vs2022"},
+				},
+				components.VsdbgRelease{
+					SemVer:         semver.MustParse("17.4.11017+1"),
+					ReleaseVersion: "17.4.11017.1",
+					SplitVersion:   []string{"17", "4", "11017", "1"},
+					Aliases:        []string{"vs2019"},
+				},
+			}))
+		})
+
+		context("when a source fails", func() {
+			it("returns an error", func() {
+				getVersions := components.MergeVersions(components.NewFeedFetcher().GetVersions)
+
+				_, err := getVersions()
+				Expect(err).To(MatchError("no release feed URL configured"))
+			})
+		})
+	})
+}
@@ -9,7 +9,11 @@ import (
 
 func TestUnit(t *testing.T) {
 	suite := spec.New("vsdbg", spec.Report(report.Terminal{}), spec.Parallel())
+	suite("Aliases", testAliases)
 	suite("Dependency", testDependency)
+	suite("Feed", testFeed)
 	suite("Releases", testReleases)
+	suite("ScriptParser", testScriptParser)
+	suite("Version", testVersion)
 	suite.Run(t)
 }
@@ -0,0 +1,81 @@
+package components
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// DefaultLicenseRef is the SPDX license reference recorded for the debugger,
+// which is distributed under the proprietary terms of its EULA rather than a
+// standard open source license.
+const DefaultLicenseRef = "LicenseRef-vsdbg-EULA"
+
+// maxLicenseSize is the size of the largest license file read from a tarball.
+const maxLicenseSize = 1 << 20
+
+// licenseFileNames are the names, without extension, of the files in a
+// debugger tarball that hold its license or EULA text.
+var licenseFileNames = []string{"eula", "license", "licence"}
+
+// findLicenseText returns the text of the first license or EULA file in the
+// gzipped tarball read from the given reader, or nil if the tarball has none.
+// Errors are not returned, as a tarball that cannot be read is reported by
+// the checksum it is recorded with rather than by its license.
+func findLicenseText(reader io.Reader) []byte {
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			return nil
+		}
+
+		if header.Typeflag != tar.TypeReg || header.Size > maxLicenseSize || !isLicenseFile(header.Name) {
+			continue
+		}
+
+		text, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil
+		}
+
+		return text
+	}
+}
+
+func isLicenseFile(name string) bool {
+	base := strings.ToLower(path.Base(name))
+	base = strings.TrimSuffix(base, path.Ext(base))
+
+	for _, licenseFileName := range licenseFileNames {
+		if base == licenseFileName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// licenseRef returns the license reference recorded for a tarball with the
+// given license text. The reference identifies the text by its digest, so
+// that a change to the terms of the debugger is a change to its reference.
+// Without a text, the given default reference is returned.
+func licenseRef(defaultRef string, text []byte) string {
+	if text == nil || defaultRef == "" {
+		return defaultRef
+	}
+
+	sum := sha256.Sum256(text)
+	return fmt.Sprintf("%s-%s", defaultRef, hex.EncodeToString(sum[:])[:12])
+}
@@ -1,9 +1,9 @@
 package components
 
 import (
-	"bufio"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -14,6 +14,30 @@ type VsdbgRelease struct {
 	SemVer         *semver.Version
 	ReleaseVersion string `json:"version"`
 	SplitVersion   []string
+
+	// Aliases are the meta versions, such as latest or vs2022, that
+	// GetVsDbg.sh maps onto this release.
+	Aliases []string
+}
+
+// MetaVersionNotFoundError is returned when GetVsDbg.sh does not declare a
+// required meta version.
+type MetaVersionNotFoundError struct {
+	Alias string
+}
+
+func (e MetaVersionNotFoundError) Error() string {
+	return fmt.Sprintf("%s version not found", e.Alias)
+}
+
+// VersionFormatError is returned when a meta version maps onto a version that
+// is not in the w.x.y.z format.
+type VersionFormatError struct {
+	Version string
+}
+
+func (e VersionFormatError) Error() string {
+	return fmt.Sprintf("unexpect version: expected %q to be in the format of w.x.y.z", e.Version)
 }
 
 type Fetcher struct {
@@ -46,47 +70,68 @@ func (f Fetcher) GetVersions() (versionology.VersionFetcherArray, error) {
 		return nil, fmt.Errorf("received a non 200 status code from %s: status code %d received", f.scriptURL, response.StatusCode)
 	}
 
-	scanner := bufio.NewScanner(response.Body)
+	metaVersions, err := ParseMetaVersions(response.Body)
+	if err != nil {
+		return nil, err
+	}
 
-	var version string
-	var inFunction, latest bool
-	for scanner.Scan() {
-		if inFunction && latest {
-			version = strings.Split(strings.TrimSpace(scanner.Text()), "=")[1]
-			break
-		}
+	var (
+		releases []versionology.VersionFetcher
+		latest   bool
+	)
 
-		if inFunction && strings.Contains(scanner.Text(), "latest)") {
-			latest = true
-			continue
+	for _, metaVersion := range metaVersions {
+		releases, err = addRelease(releases, metaVersion.Version, metaVersion.Alias)
+		if err != nil {
+			return nil, err
 		}
 
-		if strings.Contains(scanner.Text(), "set_vsdbg_version()") {
-			inFunction = true
-			continue
+		if metaVersion.Alias == "latest" {
+			latest = true
 		}
 	}
 
-	if !inFunction {
-		return nil, fmt.Errorf("set_vsdbg_version() function not found")
-	}
-
 	if !latest {
-		return nil, fmt.Errorf("latest version not found")
+		return nil, MetaVersionNotFoundError{Alias: "latest"}
 	}
 
-	var release VsdbgRelease
+	return releases, nil
+}
+
+// addRelease adds the given meta versions to the release with a matching
+// version, or appends a new release if there is none.
+func addRelease(releases []versionology.VersionFetcher, version string, aliases ...string) ([]versionology.VersionFetcher, error) {
+	index := slices.IndexFunc(releases, func(r versionology.VersionFetcher) bool {
+		return r.(VsdbgRelease).ReleaseVersion == version
+	})
+
+	if index < 0 {
+		release := VsdbgRelease{
+			ReleaseVersion: version,
+			SplitVersion:   strings.Split(version, "."),
+		}
 
-	release.ReleaseVersion = version
-	release.SplitVersion = strings.Split(version, ".")
-	if len(release.SplitVersion) != 4 {
-		return nil, fmt.Errorf("unexpect version: expected %q to be in the format of w.x.y.z", version)
+		if len(release.SplitVersion) != 4 {
+			return nil, VersionFormatError{Version: version}
+		}
+
+		var err error
+		release.SemVer, err = semver.NewVersion(fmt.Sprintf("%s+%s", strings.Join(release.SplitVersion[:3], "."), release.SplitVersion[3]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: the following version string could not be parsed %q", err, release.ReleaseVersion)
+		}
+
+		releases = append(releases, release)
+		index = len(releases) - 1
 	}
 
-	release.SemVer, err = semver.NewVersion(fmt.Sprintf("%s+%s", strings.Join(release.SplitVersion[:3], "."), release.SplitVersion[3]))
-	if err != nil {
-		return nil, fmt.Errorf("%w: the following version string could not be parsed %q", err, release.ReleaseVersion)
+	release := releases[index].(VsdbgRelease)
+	for _, alias := range aliases {
+		if !slices.Contains(release.Aliases, alias) {
+			release.Aliases = append(release.Aliases, alias)
+		}
 	}
+	releases[index] = release
 
-	return []versionology.VersionFetcher{release}, nil
+	return releases, nil
 }
@@ -65,6 +65,9 @@ func testReleases(t *testing.T, context spec.G, it spec.S) {
     case "$version_string" in
         oldest)
             __VsDbgVersion=17.4.11017.1
+            ;;
+    esac
+}
 					`)
 
 				case "/wrong-version-format":
@@ -74,7 +77,10 @@ func testReleases(t *testing.T, context spec.G, it spec.S) {
     version_string="$(echo "$1" | awk '{print tolower($0)}')"
     case "$version_string" in
         latest)
-            __VsDbgVersion=wrong format
+            __VsDbgVersion="wrong format"
+            ;;
+    esac
+}
 					`)
 
 				case "/no-version-parse":
@@ -85,6 +91,9 @@ func testReleases(t *testing.T, context spec.G, it spec.S) {
     case "$version_string" in
         latest)
             __VsDbgVersion=not.valid.semver.version
+            ;;
+    esac
+}
 					`)
 
 				default:
@@ -104,6 +113,7 @@ func testReleases(t *testing.T, context spec.G, it spec.S) {
 					SemVer:         semver.MustParse("17.4.11017+1"),
 					ReleaseVersion: "17.4.11017.1",
 					SplitVersion:   []string{"17", "4", "11017", "1"},
+					Aliases:        []string{"latest", "vs2022", "vs2019", "vsfm-8", "vs2017u5"},
 				},
 			}))
 		})
@@ -0,0 +1,440 @@
+package components
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	versionFunction = "set_vsdbg_version"
+	versionVariable = "__VsDbgVersion"
+	zipVariable     = "__UseZip"
+)
+
+// MetaVersion is a single meta version branch of the set_vsdbg_version()
+// case statement in GetVsDbg.sh.
+type MetaVersion struct {
+	Alias   string
+	Version string
+}
+
+// FunctionNotFoundError is returned when the script does not define the
+// set_vsdbg_version() function.
+type FunctionNotFoundError struct {
+	Function string
+}
+
+func (e FunctionNotFoundError) Error() string {
+	return fmt.Sprintf("%s() function not found", e.Function)
+}
+
+// CaseNotFoundError is returned when the set_vsdbg_version() function does
+// not contain a case statement.
+type CaseNotFoundError struct {
+	Function string
+}
+
+func (e CaseNotFoundError) Error() string {
+	return fmt.Sprintf("case statement not found in %s()", e.Function)
+}
+
+// UnterminatedCaseError is returned when the script ends before the case
+// statement is closed with esac.
+type UnterminatedCaseError struct {
+	Function string
+}
+
+func (e UnterminatedCaseError) Error() string {
+	return fmt.Sprintf("case statement in %s() is not terminated by esac", e.Function)
+}
+
+// MalformedCaseError is returned when the case statement does not follow the
+// POSIX case grammar.
+type MalformedCaseError struct {
+	Function string
+	Reason   string
+}
+
+func (e MalformedCaseError) Error() string {
+	return fmt.Sprintf("malformed case statement in %s(): %s", e.Function, e.Reason)
+}
+
+// MissingVersionError is returned when a meta version branch does not assign
+// a version.
+type MissingVersionError struct {
+	Alias string
+}
+
+func (e MissingVersionError) Error() string {
+	return fmt.Sprintf("meta version %q does not assign %s", e.Alias, versionVariable)
+}
+
+// functionPattern matches the definition of set_vsdbg_version() in either the
+// POSIX or the bash function syntax.
+var functionPattern = regexp.MustCompile(`(?m)^[ \t]*(?:function[ \t]+)?` + versionFunction + `[ \t]*\([ \t]*\)`)
+
+// aliasPattern matches the case patterns that name a meta version, as opposed
+// to globs such as [0-9]* or *.
+var aliasPattern = regexp.MustCompile(`^[a-z][a-z0-9.-]*$`)
+
+// ParseMetaVersions reads a GetVsDbg.sh script and returns every meta version
+// declared by the case statement of its set_vsdbg_version() function, in the
+// order in which they are declared. Comments, blank lines, quoting, pattern
+// alternatives and single line branches are all supported. Branches that set
+// __UseZip=true are skipped, as their releases are only published as zip
+// files and have no tarball to install.
+func ParseMetaVersions(r io.Reader) ([]MetaVersion, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	location := functionPattern.FindIndex(content)
+	if location == nil {
+		return nil, FunctionNotFoundError{Function: versionFunction}
+	}
+
+	tokens := tokenize(string(content[location[1]:]))
+
+	p := parser{tokens: tokens}
+	if !p.skipToCase() {
+		return nil, CaseNotFoundError{Function: versionFunction}
+	}
+
+	return p.parseCase()
+}
+
+type token struct {
+	value string
+
+	// operator is true for the control operators ;, ;;, (, ) and | as
+	// opposed to words, which may contain those characters when quoted.
+	operator bool
+}
+
+// tokenize splits shell source into words and control operators. Quotes are
+// removed from words, comments are dropped and newlines are reported as the
+// ; operator.
+func tokenize(source string) []token {
+	var (
+		tokens []token
+		word   strings.Builder
+		inWord bool
+	)
+
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, token{value: word.String()})
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	runes := []rune(source)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+
+		case c == '\n':
+			flush()
+			tokens = append(tokens, token{value: ";", operator: true})
+
+		case c == '#' && !inWord:
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			i--
+
+		case c == '\\':
+			inWord = true
+			if i+1 < len(runes) && runes[i+1] != '\n' {
+				word.WriteRune(runes[i+1])
+			}
+			i++
+
+		case c == '\'':
+			inWord = true
+			for i++; i < len(runes) && runes[i] != '\''; i++ {
+				word.WriteRune(runes[i])
+			}
+
+		case c == '"':
+			inWord = true
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				switch {
+				case runes[i] == '\\' && i+1 < len(runes):
+					i++
+					word.WriteRune(runes[i])
+				case isSubstitution(runes, i):
+					end := closingParen(runes, i+1)
+					word.WriteString(string(runes[i : end+1]))
+					i = end
+				default:
+					word.WriteRune(runes[i])
+				}
+			}
+
+		case isSubstitution(runes, i):
+			inWord = true
+			end := closingParen(runes, i+1)
+			word.WriteString(string(runes[i : end+1]))
+			i = end
+
+		case c == ';':
+			flush()
+			if i+1 < len(runes) && runes[i+1] == ';' {
+				tokens = append(tokens, token{value: ";;", operator: true})
+				i++
+			} else {
+				tokens = append(tokens, token{value: ";", operator: true})
+			}
+
+		case c == '(' || c == ')' || c == '|':
+			flush()
+			tokens = append(tokens, token{value: string(c), operator: true})
+
+		default:
+			inWord = true
+			word.WriteRune(c)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+func isSubstitution(runes []rune, i int) bool {
+	return runes[i] == '$' && i+1 < len(runes) && runes[i+1] == '('
+}
+
+// closingParen returns the index of the ) that closes the ( at index start,
+// skipping over any quoted text in between.
+func closingParen(runes []rune, start int) int {
+	depth := 0
+	for i := start; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '\'':
+			for i++; i < len(runes) && runes[i] != '\''; i++ {
+			}
+		case '"':
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return len(runes) - 1
+}
+
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.position >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.position], true
+}
+
+func (p *parser) next() (token, bool) {
+	t, ok := p.peek()
+	if ok {
+		p.position++
+	}
+	return t, ok
+}
+
+// skipSeparators advances past any ; operators, which include newlines.
+func (p *parser) skipSeparators() {
+	for {
+		t, ok := p.peek()
+		if !ok || !t.operator || t.value != ";" {
+			return
+		}
+		p.position++
+	}
+}
+
+// skipToCase advances to the first case keyword within the function body and
+// reports whether one was found.
+func (p *parser) skipToCase() bool {
+	depth := 0
+	for {
+		t, ok := p.next()
+		if !ok {
+			return false
+		}
+
+		if t.operator {
+			continue
+		}
+
+		switch t.value {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth <= 0 {
+				return false
+			}
+		case "case":
+			return true
+		}
+	}
+}
+
+func (p *parser) parseCase() ([]MetaVersion, error) {
+	if t, ok := p.next(); !ok || t.operator {
+		return nil, MalformedCaseError{Function: versionFunction, Reason: "missing case subject"}
+	}
+
+	p.skipSeparators()
+	if t, ok := p.next(); !ok || t.operator || t.value != "in" {
+		return nil, MalformedCaseError{Function: versionFunction, Reason: `missing "in" keyword`}
+	}
+
+	var versions []MetaVersion
+	for {
+		p.skipSeparators()
+
+		t, ok := p.peek()
+		if !ok {
+			return nil, UnterminatedCaseError{Function: versionFunction}
+		}
+
+		if !t.operator && t.value == "esac" {
+			return versions, nil
+		}
+
+		patterns, err := p.parsePatterns()
+		if err != nil {
+			return nil, err
+		}
+
+		version, zip, terminated, err := p.parseCommands()
+		if err != nil {
+			return nil, err
+		}
+
+		// Releases that are only published as zip files cannot be installed.
+		if zip {
+			patterns = nil
+		}
+
+		for _, pattern := range patterns {
+
+			alias := strings.ToLower(pattern)
+			if !aliasPattern.MatchString(alias) {
+				continue
+			}
+
+			if version == "" {
+				return nil, MissingVersionError{Alias: alias}
+			}
+
+			versions = append(versions, MetaVersion{Alias: alias, Version: version})
+		}
+
+		if terminated {
+			return versions, nil
+		}
+	}
+}
+
+// parsePatterns reads a |-separated list of case patterns terminated by ).
+func (p *parser) parsePatterns() ([]string, error) {
+	if t, ok := p.peek(); ok && t.operator && t.value == "(" {
+		p.position++
+	}
+
+	var patterns []string
+	for {
+		t, ok := p.next()
+		if !ok {
+			return nil, UnterminatedCaseError{Function: versionFunction}
+		}
+
+		if t.operator {
+			return nil, MalformedCaseError{Function: versionFunction, Reason: fmt.Sprintf("unexpected %q in case pattern", t.value)}
+		}
+
+		patterns = append(patterns, t.value)
+
+		t, ok = p.next()
+		if !ok {
+			return nil, UnterminatedCaseError{Function: versionFunction}
+		}
+
+		switch {
+		case t.operator && t.value == ")":
+			return patterns, nil
+		case t.operator && t.value == "|":
+			continue
+		default:
+			return nil, MalformedCaseError{Function: versionFunction, Reason: fmt.Sprintf("unexpected %q in case pattern", t.value)}
+		}
+	}
+}
+
+// parseCommands reads the commands of a case branch up to ;; or esac and
+// returns the last value assigned to __VsDbgVersion. The zip return value
+// reports whether the branch sets __UseZip=true, and the terminated return
+// value whether the branch was closed by esac rather than ;;.
+func (p *parser) parseCommands() (string, bool, bool, error) {
+	var (
+		version      string
+		zip          bool
+		commandStart = true
+	)
+
+	for {
+		t, ok := p.next()
+		if !ok {
+			return "", false, false, UnterminatedCaseError{Function: versionFunction}
+		}
+
+		if t.operator {
+			switch t.value {
+			case ";;":
+				return version, zip, false, nil
+			case ";":
+				commandStart = true
+			default:
+				commandStart = false
+			}
+			continue
+		}
+
+		if commandStart && t.value == "esac" {
+			return version, zip, true, nil
+		}
+
+		if value, ok := strings.CutPrefix(t.value, versionVariable+"="); ok && commandStart {
+			version = value
+		}
+
+		if value, ok := strings.CutPrefix(t.value, zipVariable+"="); ok && commandStart {
+			zip = value == "true"
+		}
+
+		commandStart = false
+	}
+}
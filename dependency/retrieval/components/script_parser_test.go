@@ -0,0 +1,148 @@
+package components_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paketo-buildpacks/vsdbg/dependency/retrieval/components"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testScriptParser(t *testing.T, context spec.G, it spec.S) {
+
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ParseMetaVersions", func() {
+		it("parses every meta version with a tarball from GetVsDbg.sh", func() {
+			file, err := os.Open(filepath.Join("testdata", "GetVsDbg.sh"))
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			metaVersions, err := components.ParseMetaVersions(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metaVersions).To(Equal([]components.MetaVersion{
+				{Alias: "latest", Version: "17.4.11017.1"},
+				{Alias: "vs2022", Version: "17.4.11017.1"},
+				{Alias: "vs2019", Version: "17.4.11017.1"},
+				{Alias: "vsfm-8", Version: "17.4.11017.1"},
+				{Alias: "vs2017u5", Version: "17.4.11017.1"},
+			}))
+		})
+
+		it("tolerates comments, blank lines, quoting, alternatives and reordering", func() {
+			metaVersions, err := components.ParseMetaVersions(strings.NewReader(`#!/bin/sh
+# set_vsdbg_version() is documented here but not defined
+
+function set_vsdbg_version ( )
+{
+    version_string="$(echo "$1" | awk '{print tolower($0)}')" # lower case
+    case "$version_string" in
+
+        # older releases come first
+        ( vs2017u1 )
+            __UseZip=true
+            __VsDbgVersion='15.1.10630.1' ;;
+        VS2022|vs2019) __VsDbgVersion="17.4.11017.1";;
+        latest)
+
+            # the latest release ; may be updated
+            __VsDbgVersion=17.4.11017.1
+            ;;
+        [0-9]*)
+            __VsDbgVersion=$1
+            __UseZip=$(echo "$__VsDbgVersion" | awk '{if (a[1] > 16) {print "false"} else {print "true"};}')
+            ;;
+        *)
+            fail "ERROR: '$1' does not look like a valid version number."
+    esac
+}
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metaVersions).To(Equal([]components.MetaVersion{
+				{Alias: "vs2022", Version: "17.4.11017.1"},
+				{Alias: "vs2019", Version: "17.4.11017.1"},
+				{Alias: "latest", Version: "17.4.11017.1"},
+			}))
+		})
+
+		context("failure cases", func() {
+			context("when there is no set_vsdbg_version function", func() {
+				it("returns a FunctionNotFoundError", func() {
+					_, err := components.ParseMetaVersions(strings.NewReader(`get_script_directory()
+{
+    case "$1" in
+        latest) __VsDbgVersion=17.4.11017.1 ;;
+    esac
+}
+`))
+					Expect(err).To(MatchError(components.FunctionNotFoundError{Function: "set_vsdbg_version"}))
+					Expect(err).To(MatchError("set_vsdbg_version() function not found"))
+				})
+			})
+
+			context("when the function does not contain a case statement", func() {
+				it("returns a CaseNotFoundError", func() {
+					_, err := components.ParseMetaVersions(strings.NewReader(`set_vsdbg_version()
+{
+    __VsDbgVersion=17.4.11017.1
+}
+
+other_function()
+{
+    case "$1" in
+        latest) __VsDbgVersion=17.4.11017.1 ;;
+    esac
+}
+`))
+					Expect(err).To(MatchError(components.CaseNotFoundError{Function: "set_vsdbg_version"}))
+				})
+			})
+
+			context("when the case statement is not terminated", func() {
+				it("returns an UnterminatedCaseError", func() {
+					_, err := components.ParseMetaVersions(strings.NewReader(`set_vsdbg_version()
+{
+    case "$1" in
+        latest)
+            __VsDbgVersion=17.4.11017.1
+`))
+					Expect(err).To(MatchError(components.UnterminatedCaseError{Function: "set_vsdbg_version"}))
+				})
+			})
+
+			context("when the case statement is malformed", func() {
+				it("returns a MalformedCaseError", func() {
+					_, err := components.ParseMetaVersions(strings.NewReader(`set_vsdbg_version()
+{
+    case "$1"
+        latest) __VsDbgVersion=17.4.11017.1 ;;
+    esac
+}
+`))
+					Expect(err).To(MatchError(components.MalformedCaseError{Function: "set_vsdbg_version", Reason: `missing "in" keyword`}))
+				})
+			})
+
+			context("when a meta version does not assign a version", func() {
+				it("returns a MissingVersionError", func() {
+					_, err := components.ParseMetaVersions(strings.NewReader(`set_vsdbg_version()
+{
+    case "$1" in
+        latest)
+            __UseZip=false
+            ;;
+    esac
+}
+`))
+					Expect(err).To(MatchError(components.MissingVersionError{Alias: "latest"}))
+				})
+			})
+		})
+	})
+}
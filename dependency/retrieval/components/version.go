@@ -0,0 +1,142 @@
+package components
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/paketo-buildpacks/libdependency/versionology"
+)
+
+// debuggerVersionPattern matches both the upstream form of a debugger version,
+// w.x.y.z, and its semantic version form, w.x.y+z.
+var debuggerVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:[.+](\d+))?$`)
+
+// DebuggerVersion is a four component Visual Studio Debugger version, w.x.y.z.
+// Semantic versions only have three components, so the fourth is recorded as
+// build metadata, w.x.y+z. Build metadata is ignored when semantic versions
+// are ordered, so DebuggerVersion compares all four components itself.
+type DebuggerVersion [4]uint64
+
+// ParseDebuggerVersion parses a version in either its upstream or its
+// semantic version form. A missing fourth component is treated as 0.
+func ParseDebuggerVersion(version string) (DebuggerVersion, error) {
+	matches := debuggerVersionPattern.FindStringSubmatch(version)
+	if matches == nil {
+		return DebuggerVersion{}, VersionFormatError{Version: version}
+	}
+
+	var v DebuggerVersion
+	for i, match := range matches[1:] {
+		if match == "" {
+			continue
+		}
+
+		component, err := strconv.ParseUint(match, 10, 64)
+		if err != nil {
+			return DebuggerVersion{}, VersionFormatError{Version: version}
+		}
+		v[i] = component
+	}
+
+	return v, nil
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is lower than, equal to
+// or greater than other.
+func (v DebuggerVersion) Compare(other DebuggerVersion) int {
+	return slices.Compare(v[:], other[:])
+}
+
+// String returns the upstream form of the version, w.x.y.z, as used in
+// download URLs and CPEs.
+func (v DebuggerVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v[0], v[1], v[2], v[3])
+}
+
+// SemVer returns the semantic version form of the version, w.x.y+z, as
+// recorded in buildpack.toml.
+func (v DebuggerVersion) SemVer() *semver.Version {
+	return semver.New(v[0], v[1], v[2], "", strconv.FormatUint(v[3], 10))
+}
+
+// compareVersionFetchers orders versions by all four debugger version
+// components, falling back to semantic version ordering for versions that are
+// not debugger versions.
+func compareVersionFetchers(a, b versionology.VersionFetcher) int {
+	aVersion, aErr := ParseDebuggerVersion(a.Version().Original())
+	bVersion, bErr := ParseDebuggerVersion(b.Version().Original())
+	if aErr != nil || bErr != nil {
+		return a.Version().Compare(b.Version())
+	}
+
+	return aVersion.Compare(bVersion)
+}
+
+// FilterNewVersions returns the upstream versions that satisfy at least one of
+// the constraints and are newer than every existing dependency that satisfies
+// the same constraint, keeping only the newest versions allowed by the number
+// of patches of each constraint. Without constraints, it returns the upstream
+// versions that are newer than every existing dependency.
+//
+// It mirrors versionology.FilterUpstreamVersionsByConstraints, but orders
+// versions by all four of their components so that a new build of an
+// existing w.x.y release is not mistaken for that release.
+func FilterNewVersions(id string, upstreamVersions versionology.VersionFetcherArray, constraints []versionology.Constraint, existingVersions versionology.VersionFetcherArray) versionology.VersionFetcherArray {
+	isNewer := func(version versionology.VersionFetcher, existing []versionology.VersionFetcher) bool {
+		for _, e := range existing {
+			if compareVersionFetchers(version, e) <= 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	var outputVersions versionology.VersionFetcherArray
+	for _, constraint := range constraints {
+		var existing []versionology.VersionFetcher
+		for _, version := range existingVersions {
+			if constraint.Check(version) {
+				existing = append(existing, version)
+			}
+		}
+
+		var newVersions []versionology.VersionFetcher
+		for _, version := range upstreamVersions {
+			if constraint.Check(version) && isNewer(version, existing) {
+				newVersions = append(newVersions, version)
+			}
+		}
+
+		slices.SortFunc(newVersions, compareVersionFetchers)
+		if constraint.Patches < len(newVersions) {
+			newVersions = newVersions[len(newVersions)-constraint.Patches:]
+		}
+
+		versionology.LogAllVersions(id, fmt.Sprintf("newer than existing versions for constraint %s, after limiting for %d patches", constraint.Constraint.String(), constraint.Patches), newVersions)
+
+		for _, version := range newVersions {
+			if !slices.ContainsFunc(outputVersions, func(v versionology.VersionFetcher) bool { return compareVersionFetchers(v, version) == 0 }) {
+				outputVersions = append(outputVersions, version)
+			}
+		}
+	}
+
+	if len(constraints) < 1 {
+		for _, version := range upstreamVersions {
+			if isNewer(version, existingVersions) {
+				outputVersions = append(outputVersions, version)
+			}
+		}
+	}
+
+	slices.SortStableFunc(outputVersions, func(a, b versionology.VersionFetcher) int {
+		return cmp.Compare(0, compareVersionFetchers(a, b))
+	})
+
+	versionology.LogAllVersions(id, "as new versions", outputVersions)
+	return outputVersions
+}
@@ -0,0 +1,128 @@
+package components_test
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/paketo-buildpacks/libdependency/versionology"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/vsdbg/dependency/retrieval/components"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testVersion(t *testing.T, context spec.G, it spec.S) {
+
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	context("ParseDebuggerVersion", func() {
+		it("parses the upstream and semantic version forms", func() {
+			dotted, err := components.ParseDebuggerVersion("18.7.10521.2")
+			Expect(err).NotTo(HaveOccurred())
+
+			semantic, err := components.ParseDebuggerVersion("18.7.10521+2")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(dotted).To(Equal(semantic))
+			Expect(dotted.String()).To(Equal("18.7.10521.2"))
+			Expect(dotted.SemVer().String()).To(Equal("18.7.10521+2"))
+		})
+
+		it("orders all four components", func() {
+			lower, err := components.ParseDebuggerVersion("18.7.10521.2")
+			Expect(err).NotTo(HaveOccurred())
+
+			higher, err := components.ParseDebuggerVersion("18.7.10521.10")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(lower.Compare(higher)).To(Equal(-1))
+			Expect(higher.Compare(lower)).To(Equal(1))
+			Expect(lower.Compare(lower)).To(Equal(0))
+		})
+
+		context("failure cases", func() {
+			context("when the version is not a debugger version", func() {
+				it("returns a VersionFormatError", func() {
+					_, err := components.ParseDebuggerVersion("18.7.x.2")
+					Expect(err).To(MatchError(components.VersionFormatError{Version: "18.7.x.2"}))
+				})
+			})
+		})
+	})
+
+	context("FilterNewVersions", func() {
+		var (
+			release = func(version string) components.VsdbgRelease {
+				v, err := components.ParseDebuggerVersion(version)
+				Expect(err).NotTo(HaveOccurred())
+
+				return components.VsdbgRelease{SemVer: v.SemVer(), ReleaseVersion: v.String()}
+			}
+
+			existing = func(version string) versionology.Dependency {
+				dependency, err := versionology.NewDependency(cargo.ConfigMetadataDependency{ID: "vsdbg", Version: version}, "*")
+				Expect(err).NotTo(HaveOccurred())
+
+				return dependency
+			}
+
+			constraint = func(c string, patches int) versionology.Constraint {
+				con, err := versionology.NewConstraint(cargo.ConfigMetadataDependencyConstraint{ID: "vsdbg", Constraint: c, Patches: patches})
+				Expect(err).NotTo(HaveOccurred())
+
+				return con
+			}
+
+			releaseVersions = func(versions versionology.VersionFetcherArray) []string {
+				var releaseVersions []string
+				for _, version := range versions {
+					releaseVersions = append(releaseVersions, version.(components.VsdbgRelease).ReleaseVersion)
+				}
+				return releaseVersions
+			}
+		)
+
+		it("returns new builds of existing releases", func() {
+			versions := components.FilterNewVersions("vsdbg",
+				versionology.VersionFetcherArray{release("18.7.10521.3"), release("18.7.10521.2"), release("18.7.10521.1")},
+				[]versionology.Constraint{constraint("*", 2)},
+				versionology.VersionFetcherArray{existing("18.7.10521+2")},
+			)
+
+			Expect(releaseVersions(versions)).To(Equal([]string{"18.7.10521.3"}))
+		})
+
+		it("limits each constraint to its newest patches across all four components", func() {
+			versions := components.FilterNewVersions("vsdbg",
+				versionology.VersionFetcherArray{release("18.7.10521.2"), release("18.7.10521.10"), release("18.7.10521.1"), release("17.4.11017.1")},
+				[]versionology.Constraint{constraint("18.*", 2), constraint("17.*", 1)},
+				nil,
+			)
+
+			Expect(releaseVersions(versions)).To(Equal([]string{"18.7.10521.10", "18.7.10521.2", "17.4.11017.1"}))
+		})
+
+		it("returns versions newer than every existing version when there are no constraints", func() {
+			versions := components.FilterNewVersions("vsdbg",
+				versionology.VersionFetcherArray{release("18.7.10521.3"), release("18.7.10521.2"), release("17.4.11017.1")},
+				nil,
+				versionology.VersionFetcherArray{existing("18.7.10521+2")},
+			)
+
+			Expect(releaseVersions(versions)).To(Equal([]string{"18.7.10521.3"}))
+		})
+
+		it("orders semantic versions without a fourth component", func() {
+			versions := components.FilterNewVersions("vsdbg",
+				versionology.VersionFetcherArray{versionology.NewSimpleVersionFetcher(semver.MustParse("2.0.0"))},
+				nil,
+				versionology.VersionFetcherArray{existing("1.0.0")},
+			)
+
+			Expect(versions).To(HaveLen(1))
+		})
+	})
+}
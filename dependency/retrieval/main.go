@@ -1,12 +1,166 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paketo-buildpacks/libdependency/buildpack_config"
 	"github.com/paketo-buildpacks/libdependency/retrieve"
+	"github.com/paketo-buildpacks/libdependency/versionology"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/fs"
 	"github.com/paketo-buildpacks/vsdbg/dependency/retrieval/components"
 )
 
+// VSDBG_VERSION_SOURCES is a comma separated list of the sources to discover
+// releases from: "script" for GetVsDbg.sh and "feed" for the JSON release feed
+// at VSDBG_RELEASE_FEED_URL. Releases from several sources are merged.
+//
+// With --update-version-aliases, the version-aliases table and the
+// dependency-constraints of buildpack.toml are rewritten from the meta
+// versions of the upstream releases instead. With
+// --check-version-aliases, every meta version in buildpack.toml is checked to
+// resolve to a dependency of each target.
 func main() {
-	fetcher := components.NewFetcher()
+	var updateVersionAliases, checkVersionAliases bool
+	flag.BoolVar(&updateVersionAliases, "update-version-aliases", false, "rewrite the version-aliases and dependency-constraints of buildpack.toml from the upstream meta versions")
+	flag.BoolVar(&checkVersionAliases, "check-version-aliases", false, "check that every version alias of buildpack.toml resolves to a dependency")
+
+	buildpackTomlPath, output := retrieve.FetchArgs()
+	if exists, err := fs.Exists(buildpackTomlPath); err != nil {
+		panic(err)
+	} else if !exists {
+		panic(fmt.Errorf("could not locate buildpack.toml at '%s'", buildpackTomlPath))
+	}
+
+	if checkVersionAliases {
+		config, err := buildpack_config.ParseBuildpackToml(buildpackTomlPath)
+		if err != nil {
+			panic(err)
+		}
+
+		if err := components.CheckVersionAliases("vsdbg", config); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("All version aliases in %s resolve\n", buildpackTomlPath)
+		return
+	}
+
+	sources := []string{"script"}
+	if value, ok := os.LookupEnv("VSDBG_VERSION_SOURCES"); ok {
+		sources = strings.Split(value, ",")
+	}
+
+	var getVersions []retrieve.GetAllVersionsFunc
+	for _, source := range sources {
+		switch strings.TrimSpace(source) {
+		case "script":
+			getVersions = append(getVersions, components.NewFetcher().GetVersions)
+		case "feed":
+			getVersions = append(getVersions, components.NewFeedFetcher().WithFeedURL(os.Getenv("VSDBG_RELEASE_FEED_URL")).GetVersions)
+		default:
+			panic(fmt.Errorf("unknown version source %q", source))
+		}
+	}
+
+	if updateVersionAliases {
+		updateVersionAliasesFromUpstream(buildpackTomlPath, components.MergeVersions(getVersions...))
+		return
+	}
+
 	generator := components.NewGenerator()
-	retrieve.NewMetadataWithPlatforms("vsdbg", fetcher.GetVersions, generator.GenerateMetadata)
+	newMetadataWithPlatforms("vsdbg", buildpackTomlPath, output, components.MergeVersions(getVersions...), generator.GenerateMetadata)
+}
+
+// updateVersionAliasesFromUpstream rewrites the version-aliases table of
+// buildpack.toml from the meta versions of the upstream releases, with a
+// dependency constraint for the release of each meta version.
+func updateVersionAliasesFromUpstream(buildpackTomlPath string, getAllVersions retrieve.GetAllVersionsFunc) {
+	config, err := buildpack_config.ParseBuildpackToml(buildpackTomlPath)
+	if err != nil {
+		panic(err)
+	}
+
+	upstreamVersions, err := getAllVersions()
+	if err != nil {
+		panic(err)
+	}
+
+	aliases := components.VersionAliases(upstreamVersions)
+	for _, alias := range aliases {
+		fmt.Printf("Version alias %s: %s\n", alias.Alias, alias.Constraint)
+	}
+
+	file, err := os.Create(buildpackTomlPath)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	if err = cargo.EncodeConfig(file, components.SetDependencyConstraints(components.SetVersionAliases(config, aliases), "vsdbg", aliases)); err != nil {
+		panic(fmt.Errorf("cannot write to %s: %w", buildpackTomlPath, err))
+	}
+	fmt.Printf("Wrote version aliases to %s\n", buildpackTomlPath)
+}
+
+// newMetadataWithPlatforms is retrieve.NewMetadataWithPlatforms with the new
+// upstream versions selected by components.FilterNewVersions, which orders all
+// four components of a debugger version rather than only the semantic version
+// that ignores the fourth.
+func newMetadataWithPlatforms(id, buildpackTomlPath, output string, getAllVersions retrieve.GetAllVersionsFunc, generateMetadata retrieve.GenerateMetadataWithPlatformFunc) {
+	if output == "" {
+		panic("metadataFile is required")
+	}
+
+	config, err := buildpack_config.ParseBuildpackToml(buildpackTomlPath)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(config.Targets) == 0 {
+		config.Targets = []cargo.ConfigTarget{{OS: "linux", Arch: "amd64"}}
+	}
+
+	upstreamVersions, err := getAllVersions()
+	if err != nil {
+		panic(err)
+	}
+	versionology.LogAllVersions(id, "from upstream", upstreamVersions)
+
+	dependencies, err := buildpack_config.GetDependenciesById(id, config)
+	if err != nil {
+		panic(err)
+	}
+
+	existingVersions := make(versionology.VersionFetcherArray, len(dependencies))
+	for i := range dependencies {
+		existingVersions[i] = dependencies[i]
+	}
+
+	constraints, err := buildpack_config.GetConstraintsById(id, config)
+	if err != nil {
+		panic(err)
+	}
+
+	newVersions := components.FilterNewVersions(id, upstreamVersions, constraints, existingVersions)
+
+	var metadata []versionology.Dependency
+	for _, target := range config.Targets {
+		platform := retrieve.Platform{OS: target.OS, Arch: target.Arch}
+		metadata = append(metadata, retrieve.GenerateAllMetadataWithPlatform(newVersions, generateMetadata, platform)...)
+	}
+
+	content, err := json.Marshal(metadata)
+	if err != nil {
+		panic(fmt.Errorf("unable to marshall metadata json, with error=%w", err))
+	}
+
+	if err = os.WriteFile(output, content, os.ModePerm); err != nil {
+		panic(fmt.Errorf("cannot write to %s: %w", output, err))
+	}
+	fmt.Printf("Wrote metadata to %s\n", output)
 }
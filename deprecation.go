@@ -0,0 +1,74 @@
+package vsdbg
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// deprecationPolicy decides how far ahead of its deprecation date the
+// selected debugger is reported, and whether that fails the build.
+type deprecationPolicy struct {
+	window time.Duration
+	fail   bool
+}
+
+func parseDeprecationPolicy() (deprecationPolicy, error) {
+	policy := deprecationPolicy{
+		window: defaultDeprecationWarningDays * 24 * time.Hour,
+	}
+
+	if value, ok := os.LookupEnv(DeprecationWarningDaysEnvVar); ok {
+		days, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return deprecationPolicy{}, fmt.Errorf("failed to parse %s value %q: %w", DeprecationWarningDaysEnvVar, value, err)
+		}
+		policy.window = time.Duration(days) * 24 * time.Hour
+	}
+
+	if value, ok := os.LookupEnv(FailOnDeprecatedEnvVar); ok {
+		var err error
+		policy.fail, err = strconv.ParseBool(value)
+		if err != nil {
+			return deprecationPolicy{}, fmt.Errorf("failed to parse %s value %q: %w", FailOnDeprecatedEnvVar, value, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// check logs a warning when the dependency is deprecated or will be within
+// the window of the policy, and returns an error instead of continuing when
+// the policy fails on deprecation.
+func (p deprecationPolicy) check(logger scribe.Emitter, dependency postal.Dependency, now time.Time) error {
+	deprecationDate := dependency.DeprecationDate
+	if deprecationDate.IsZero() {
+		return nil
+	}
+
+	date := deprecationDate.Format("2006-01-02")
+
+	switch {
+	case !deprecationDate.After(now):
+		logger.Action("Version %s of %s is deprecated.", dependency.Version, dependency.Name)
+		logger.Action("Migrate your application to a supported version of %s.", dependency.Name)
+
+		if p.fail {
+			return fmt.Errorf("version %s of %s is deprecated since %s and %s is true", dependency.Version, dependency.Name, date, FailOnDeprecatedEnvVar)
+		}
+
+	case deprecationDate.Add(-p.window).Before(now):
+		logger.Action("Version %s of %s will be deprecated after %s.", dependency.Version, dependency.Name, date)
+		logger.Action("Migrate your application to a supported version of %s before this time.", dependency.Name)
+
+		if p.fail {
+			return fmt.Errorf("version %s of %s will be deprecated after %s and %s is true", dependency.Version, dependency.Name, date, FailOnDeprecatedEnvVar)
+		}
+	}
+
+	return nil
+}
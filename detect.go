@@ -1,15 +1,161 @@
 package vsdbg
 
-import "github.com/paketo-buildpacks/packit/v2"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 
-func Detect() packit.DetectFunc {
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+//go:generate faux --interface VersionParser --output fakes/version_parser.go
+
+// VersionParser defines the interface for reading a Visual Studio Debugger
+// version constraint from a file in the application source.
+type VersionParser interface {
+	ParseVersion(path string) (version string, err error)
+}
+
+// BuildPlanMetadata is the buildpack specific data included in build plan
+// requirements.
+type BuildPlanMetadata struct {
+	Version       string `toml:"version,omitempty"`
+	VersionSource string `toml:"version-source,omitempty"`
+	Launch        bool   `toml:"launch"`
+}
+
+// projectFilePatterns are the file patterns that identify a .NET application
+// in the project directory.
+var projectFilePatterns = []string{
+	"*.csproj",
+	"*.fsproj",
+	"*.vbproj",
+	"*.sln",
+	"*.runtimeconfig.json",
+}
+
+// msbuildProjectPatterns are the file patterns of project files that may
+// declare a VsdbgVersion MSBuild property.
+var msbuildProjectPatterns = []string{
+	"*.csproj",
+	"*.fsproj",
+	"*.vbproj",
+}
+
+func Detect(buildpackYMLParser, projectFileParser VersionParser) packit.DetectFunc {
 	return func(context packit.DetectContext) (packit.DetectResult, error) {
-		return packit.DetectResult{
-			Plan: packit.BuildPlan{
-				Provides: []packit.BuildPlanProvision{
-					{Name: PlanDependencyVSDBG},
-				},
+		config, err := parseBuildpackConfig(filepath.Join(context.CNBPath, "buildpack.toml"))
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		target := targetFromEnvironment()
+		if !config.supportsTarget(PlanDependencyVSDBG, target) {
+			return packit.DetectResult{}, packit.Fail.WithMessage("no Visual Studio Debugger dependency supports the %s target", target)
+		}
+
+		plan := packit.BuildPlan{
+			Provides: []packit.BuildPlanProvision{
+				{Name: PlanDependencyVSDBG},
+			},
+		}
+
+		debugEnabled := false
+		if value, ok := os.LookupEnv(DebugEnabledEnvVar); ok {
+			debugEnabled, err = strconv.ParseBool(value)
+			if err != nil {
+				return packit.DetectResult{}, fmt.Errorf("failed to parse %s value %q: %w", DebugEnabledEnvVar, value, err)
+			}
+		}
+
+		// The debugger is only required, and a declared version only
+		// recorded, when debugging is enabled for a .NET application, so that
+		// other applications are not given a layer that is never used.
+		if !debugEnabled {
+			return packit.DetectResult{
+				Plan: plan,
+			}, nil
+		}
+
+		projectDir := filepath.Join(context.WorkingDir, os.Getenv(ProjectPathEnvVar))
+
+		projectFile, err := findFile(projectDir, projectFilePatterns)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		if projectFile == "" {
+			return packit.DetectResult{
+				Plan: plan,
+			}, nil
+		}
+
+		plan.Requires = append(plan.Requires, packit.BuildPlanRequirement{
+			Name: PlanDependencyVSDBG,
+			Metadata: BuildPlanMetadata{
+				Launch: true,
 			},
+		})
+
+		msbuildProject, err := findFile(projectDir, msbuildProjectPatterns)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		if msbuildProject != "" {
+			version, err := projectFileParser.ParseVersion(msbuildProject)
+			if err != nil {
+				return packit.DetectResult{}, err
+			}
+
+			if version != "" {
+				plan.Requires = append(plan.Requires, packit.BuildPlanRequirement{
+					Name: PlanDependencyVSDBG,
+					Metadata: BuildPlanMetadata{
+						Version:       version,
+						VersionSource: filepath.Base(msbuildProject),
+						Launch:        true,
+					},
+				})
+			}
+		}
+
+		version, err := buildpackYMLParser.ParseVersion(filepath.Join(context.WorkingDir, BuildpackYMLSource))
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		if version != "" {
+			plan.Requires = append(plan.Requires, packit.BuildPlanRequirement{
+				Name: PlanDependencyVSDBG,
+				Metadata: BuildPlanMetadata{
+					Version:       version,
+					VersionSource: BuildpackYMLSource,
+					Launch:        true,
+				},
+			})
+		}
+
+		return packit.DetectResult{
+			Plan: plan,
 		}, nil
 	}
 }
+
+// findFile returns the path of the first file in the directory that matches
+// one of the given patterns, or an empty string if there is none.
+func findFile(dir string, patterns []string) (string, error) {
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return "", fmt.Errorf("failed to glob %s: %w", pattern, err)
+		}
+
+		if len(matches) > 0 {
+			return matches[0], nil
+		}
+	}
+
+	return "", nil
+}
@@ -1,11 +1,14 @@
 package vsdbg_test
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/paketo-buildpacks/packit/v2"
 	vsdbg "github.com/paketo-buildpacks/vsdbg"
+	"github.com/paketo-buildpacks/vsdbg/fakes"
 	"github.com/sclevine/spec"
 
 	. "github.com/onsi/gomega"
@@ -15,8 +18,11 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 	var (
 		Expect = NewWithT(t).Expect
 
-		workingDir string
-		detect     packit.DetectFunc
+		workingDir         string
+		cnbDir             string
+		buildpackYMLParser *fakes.VersionParser
+		projectFileParser  *fakes.VersionParser
+		detect             packit.DetectFunc
 	)
 
 	it.Before(func() {
@@ -24,16 +30,37 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		workingDir, err = os.MkdirTemp("", "working-dir")
 		Expect(err).NotTo(HaveOccurred())
 
-		detect = vsdbg.Detect()
+		cnbDir, err = os.MkdirTemp("", "cnb")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte(`
+[metadata]
+  [[metadata.dependencies]]
+    id = "vsdbg"
+    os = "linux"
+    arch = "amd64"
+    stacks = ["*"]
+    version = "17.4.11017+1"
+`), 0600)).To(Succeed())
+
+		t.Setenv("CNB_TARGET_OS", "linux")
+		t.Setenv("CNB_TARGET_ARCH", "amd64")
+
+		buildpackYMLParser = &fakes.VersionParser{}
+		projectFileParser = &fakes.VersionParser{}
+
+		detect = vsdbg.Detect(buildpackYMLParser, projectFileParser)
 	})
 
 	it.After(func() {
 		Expect(os.RemoveAll(workingDir)).To(Succeed())
+		Expect(os.RemoveAll(cnbDir)).To(Succeed())
 	})
 
-	it("always passes detection", func() {
+	it("passes detection", func() {
 		result, err := detect(packit.DetectContext{
 			WorkingDir: workingDir,
+			CNBPath:    cnbDir,
 		})
 		Expect(err).NotTo(HaveOccurred())
 		Expect(result.Plan).To(Equal(packit.BuildPlan{
@@ -41,5 +68,310 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				{Name: "vsdbg"},
 			},
 		}))
+
+		Expect(buildpackYMLParser.ParseVersionCall.CallCount).To(Equal(0))
+		Expect(projectFileParser.ParseVersionCall.CallCount).To(Equal(0))
+	})
+
+	context("when a version is declared but BP_DEBUG_ENABLED is not set", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "app.csproj"), nil, 0600)).To(Succeed())
+			projectFileParser.ParseVersionCall.Returns.Version = "17.4.*"
+			buildpackYMLParser.ParseVersionCall.Returns.Version = "17.4.*"
+		})
+
+		it("only provides vsdbg", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan).To(Equal(packit.BuildPlan{
+				Provides: []packit.BuildPlanProvision{
+					{Name: "vsdbg"},
+				},
+			}))
+		})
+	})
+
+	context("when a project file declares a version", func() {
+		it.Before(func() {
+			t.Setenv("BP_DEBUG_ENABLED", "true")
+			Expect(os.WriteFile(filepath.Join(workingDir, "app.csproj"), nil, 0600)).To(Succeed())
+			projectFileParser.ParseVersionCall.Returns.Version = "17.4.*"
+		})
+
+		it("requires the declared version", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan).To(Equal(packit.BuildPlan{
+				Provides: []packit.BuildPlanProvision{
+					{Name: "vsdbg"},
+				},
+				Requires: []packit.BuildPlanRequirement{
+					{
+						Name: "vsdbg",
+						Metadata: vsdbg.BuildPlanMetadata{
+							Launch: true,
+						},
+					},
+					{
+						Name: "vsdbg",
+						Metadata: vsdbg.BuildPlanMetadata{
+							Version:       "17.4.*",
+							VersionSource: "app.csproj",
+							Launch:        true,
+						},
+					},
+				},
+			}))
+
+			Expect(projectFileParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, "app.csproj")))
+		})
+	})
+
+	context("when buildpack.yml declares a version", func() {
+		it.Before(func() {
+			t.Setenv("BP_DEBUG_ENABLED", "true")
+			Expect(os.WriteFile(filepath.Join(workingDir, "app.runtimeconfig.json"), nil, 0600)).To(Succeed())
+			buildpackYMLParser.ParseVersionCall.Returns.Version = "17.4.*"
+		})
+
+		it("requires the declared version", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan).To(Equal(packit.BuildPlan{
+				Provides: []packit.BuildPlanProvision{
+					{Name: "vsdbg"},
+				},
+				Requires: []packit.BuildPlanRequirement{
+					{
+						Name: "vsdbg",
+						Metadata: vsdbg.BuildPlanMetadata{
+							Launch: true,
+						},
+					},
+					{
+						Name: "vsdbg",
+						Metadata: vsdbg.BuildPlanMetadata{
+							Version:       "17.4.*",
+							VersionSource: "buildpack.yml",
+							Launch:        true,
+						},
+					},
+				},
+			}))
+		})
+	})
+
+	context("when BP_DEBUG_ENABLED is true", func() {
+		it.Before(func() {
+			t.Setenv("BP_DEBUG_ENABLED", "true")
+		})
+
+		context("when the working directory contains a .NET project file", func() {
+			for _, file := range []string{"app.csproj", "app.fsproj", "app.vbproj", "app.sln", "app.runtimeconfig.json"} {
+				it("requires vsdbg at launch for "+file, func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, file), nil, 0600)).To(Succeed())
+
+					result, err := detect(packit.DetectContext{
+						WorkingDir: workingDir,
+						CNBPath:    cnbDir,
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.Plan).To(Equal(packit.BuildPlan{
+						Provides: []packit.BuildPlanProvision{
+							{Name: "vsdbg"},
+						},
+						Requires: []packit.BuildPlanRequirement{
+							{
+								Name: "vsdbg",
+								Metadata: vsdbg.BuildPlanMetadata{
+									Launch: true,
+								},
+							},
+						},
+					}))
+				})
+			}
+		})
+
+		context("when BP_DOTNET_PROJECT_PATH is set", func() {
+			it.Before(func() {
+				t.Setenv("BP_DOTNET_PROJECT_PATH", "src/app")
+
+				Expect(os.MkdirAll(filepath.Join(workingDir, "src", "app"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "src", "app", "app.csproj"), nil, 0600)).To(Succeed())
+				projectFileParser.ParseVersionCall.Returns.Version = "18.7.*"
+			})
+
+			it("reads the project file in the project directory", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan).To(Equal(packit.BuildPlan{
+					Provides: []packit.BuildPlanProvision{
+						{Name: "vsdbg"},
+					},
+					Requires: []packit.BuildPlanRequirement{
+						{
+							Name: "vsdbg",
+							Metadata: vsdbg.BuildPlanMetadata{
+								Launch: true,
+							},
+						},
+						{
+							Name: "vsdbg",
+							Metadata: vsdbg.BuildPlanMetadata{
+								Version:       "18.7.*",
+								VersionSource: "app.csproj",
+								Launch:        true,
+							},
+						},
+					},
+				}))
+
+				Expect(projectFileParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, "src", "app", "app.csproj")))
+			})
+
+			context("when the project file is only in the working directory", func() {
+				it.Before(func() {
+					Expect(os.RemoveAll(filepath.Join(workingDir, "src"))).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(workingDir, "app.csproj"), nil, 0600)).To(Succeed())
+				})
+
+				it("only provides vsdbg", func() {
+					result, err := detect(packit.DetectContext{
+						WorkingDir: workingDir,
+						CNBPath:    cnbDir,
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.Plan).To(Equal(packit.BuildPlan{
+						Provides: []packit.BuildPlanProvision{
+							{Name: "vsdbg"},
+						},
+					}))
+				})
+			})
+		})
+
+		context("when the working directory does not contain a .NET project file", func() {
+			it("only provides vsdbg", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan).To(Equal(packit.BuildPlan{
+					Provides: []packit.BuildPlanProvision{
+						{Name: "vsdbg"},
+					},
+				}))
+			})
+		})
+	})
+
+	context("when BP_DEBUG_ENABLED is false", func() {
+		it.Before(func() {
+			t.Setenv("BP_DEBUG_ENABLED", "false")
+			Expect(os.WriteFile(filepath.Join(workingDir, "app.csproj"), nil, 0600)).To(Succeed())
+		})
+
+		it("only provides vsdbg", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan).To(Equal(packit.BuildPlan{
+				Provides: []packit.BuildPlanProvision{
+					{Name: "vsdbg"},
+				},
+			}))
+		})
+	})
+
+	context("when no dependency supports the target", func() {
+		it.Before(func() {
+			t.Setenv("CNB_TARGET_ARCH", "arm64")
+		})
+
+		it("fails detection", func() {
+			_, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+			})
+			Expect(err).To(MatchError(packit.Fail.WithMessage("no Visual Studio Debugger dependency supports the linux/arm64 target")))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when buildpack.toml cannot be parsed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(cnbDir, "buildpack.toml"), []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring("failed to parse buildpack.toml")))
+			})
+		})
+
+		context("when the project file cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("BP_DEBUG_ENABLED", "true")
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.csproj"), nil, 0600)).To(Succeed())
+				projectFileParser.ParseVersionCall.Returns.Err = errors.New("failed to parse project file")
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).To(MatchError("failed to parse project file"))
+			})
+		})
+
+		context("when buildpack.yml cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("BP_DEBUG_ENABLED", "true")
+				Expect(os.WriteFile(filepath.Join(workingDir, "app.csproj"), nil, 0600)).To(Succeed())
+				buildpackYMLParser.ParseVersionCall.Returns.Err = errors.New("failed to parse buildpack.yml")
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).To(MatchError("failed to parse buildpack.yml"))
+			})
+		})
+
+		context("when BP_DEBUG_ENABLED cannot be parsed", func() {
+			it.Before(func() {
+				t.Setenv("BP_DEBUG_ENABLED", "not-a-bool")
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse BP_DEBUG_ENABLED value "not-a-bool"`)))
+			})
+		})
 	})
 }
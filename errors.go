@@ -0,0 +1,70 @@
+package vsdbg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AvailableDependency is a version and platform of the debugger listed in
+// buildpack.toml.
+type AvailableDependency struct {
+	Version string
+	OS      string
+	Arch    string
+}
+
+// ResolutionError is returned by Build when no dependency in buildpack.toml
+// satisfies the requested version on the build target. It lists the versions
+// that are available so that a satisfiable version can be chosen.
+type ResolutionError struct {
+	// Version is the version requested in the build plan, which may be a meta
+	// version.
+	Version string
+
+	// Constraint is the version constraint that was resolved, if it differs
+	// from the requested version.
+	Constraint string
+
+	// VersionSource is where the requested version came from, such as
+	// BP_VSDBG_VERSION or a project file.
+	VersionSource string
+
+	Target    string
+	Stack     string
+	Available []AvailableDependency
+	Err       error
+}
+
+func (e ResolutionError) Error() string {
+	var message strings.Builder
+
+	version := e.Version
+	if version == "" {
+		version = "default"
+	}
+
+	fmt.Fprintf(&message, "failed to resolve %s version %q", PlanDependencyVSDBG, version)
+	if e.Constraint != "" {
+		fmt.Fprintf(&message, " (%s)", e.Constraint)
+	}
+	if e.VersionSource != "" {
+		fmt.Fprintf(&message, " requested by %s", e.VersionSource)
+	}
+	fmt.Fprintf(&message, " for target %s and stack %q: %s", e.Target, e.Stack, e.Err)
+
+	if len(e.Available) == 0 {
+		message.WriteString("\nno versions are available")
+		return message.String()
+	}
+
+	message.WriteString("\navailable versions:")
+	for _, dependency := range e.Available {
+		fmt.Fprintf(&message, "\n  %s %s/%s", dependency.Version, dependency.OS, dependency.Arch)
+	}
+
+	return message.String()
+}
+
+func (e ResolutionError) Unwrap() error {
+	return e.Err
+}
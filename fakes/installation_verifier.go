@@ -0,0 +1,58 @@
+package fakes
+
+import (
+	"sync"
+
+	"github.com/paketo-buildpacks/vsdbg"
+)
+
+type InstallationVerifier struct {
+	CheckGLIBCCall struct {
+		sync.Mutex
+		CallCount int
+		Receives  struct {
+			Path string
+			Arch string
+		}
+		Returns struct {
+			GLIBCReport vsdbg.GLIBCReport
+			Error       error
+		}
+		Stub func(string, string) (vsdbg.GLIBCReport, error)
+	}
+	VerifyCall struct {
+		sync.Mutex
+		CallCount int
+		Receives  struct {
+			Path string
+			Arch string
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(string, string) error
+	}
+}
+
+func (f *InstallationVerifier) CheckGLIBC(param1 string, param2 string) (vsdbg.GLIBCReport, error) {
+	f.CheckGLIBCCall.Lock()
+	defer f.CheckGLIBCCall.Unlock()
+	f.CheckGLIBCCall.CallCount++
+	f.CheckGLIBCCall.Receives.Path = param1
+	f.CheckGLIBCCall.Receives.Arch = param2
+	if f.CheckGLIBCCall.Stub != nil {
+		return f.CheckGLIBCCall.Stub(param1, param2)
+	}
+	return f.CheckGLIBCCall.Returns.GLIBCReport, f.CheckGLIBCCall.Returns.Error
+}
+func (f *InstallationVerifier) Verify(param1 string, param2 string) error {
+	f.VerifyCall.Lock()
+	defer f.VerifyCall.Unlock()
+	f.VerifyCall.CallCount++
+	f.VerifyCall.Receives.Path = param1
+	f.VerifyCall.Receives.Arch = param2
+	if f.VerifyCall.Stub != nil {
+		return f.VerifyCall.Stub(param1, param2)
+	}
+	return f.VerifyCall.Returns.Error
+}
@@ -3,6 +3,7 @@ package fakes
 import (
 	"sync"
 
+	"github.com/paketo-buildpacks/packit/v2/postal"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
 )
 
@@ -19,6 +20,32 @@ type SBOMGenerator struct {
 		}
 		Stub func(string) (sbom.SBOM, error)
 	}
+	GenerateFromDependencyCall struct {
+		sync.Mutex
+		CallCount int
+		Receives  struct {
+			Dependency postal.Dependency
+			Dir        string
+		}
+		Returns struct {
+			SBOM  sbom.SBOM
+			Error error
+		}
+		Stub func(postal.Dependency, string) (sbom.SBOM, error)
+	}
+	GenerateWithDependencyCall struct {
+		sync.Mutex
+		CallCount int
+		Receives  struct {
+			Dependency postal.Dependency
+			Dir        string
+		}
+		Returns struct {
+			SBOM  sbom.SBOM
+			Error error
+		}
+		Stub func(postal.Dependency, string) (sbom.SBOM, error)
+	}
 }
 
 func (f *SBOMGenerator) Generate(param1 string) (sbom.SBOM, error) {
@@ -31,3 +58,25 @@ func (f *SBOMGenerator) Generate(param1 string) (sbom.SBOM, error) {
 	}
 	return f.GenerateCall.Returns.SBOM, f.GenerateCall.Returns.Error
 }
+func (f *SBOMGenerator) GenerateFromDependency(param1 postal.Dependency, param2 string) (sbom.SBOM, error) {
+	f.GenerateFromDependencyCall.Lock()
+	defer f.GenerateFromDependencyCall.Unlock()
+	f.GenerateFromDependencyCall.CallCount++
+	f.GenerateFromDependencyCall.Receives.Dependency = param1
+	f.GenerateFromDependencyCall.Receives.Dir = param2
+	if f.GenerateFromDependencyCall.Stub != nil {
+		return f.GenerateFromDependencyCall.Stub(param1, param2)
+	}
+	return f.GenerateFromDependencyCall.Returns.SBOM, f.GenerateFromDependencyCall.Returns.Error
+}
+func (f *SBOMGenerator) GenerateWithDependency(param1 postal.Dependency, param2 string) (sbom.SBOM, error) {
+	f.GenerateWithDependencyCall.Lock()
+	defer f.GenerateWithDependencyCall.Unlock()
+	f.GenerateWithDependencyCall.CallCount++
+	f.GenerateWithDependencyCall.Receives.Dependency = param1
+	f.GenerateWithDependencyCall.Receives.Dir = param2
+	if f.GenerateWithDependencyCall.Stub != nil {
+		return f.GenerateWithDependencyCall.Stub(param1, param2)
+	}
+	return f.GenerateWithDependencyCall.Returns.SBOM, f.GenerateWithDependencyCall.Returns.Error
+}
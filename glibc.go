@@ -0,0 +1,93 @@
+package vsdbg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// glibcCheck is the action taken when the installed debugger imports glibc
+// symbol versions that the stack does not provide.
+type glibcCheck string
+
+const (
+	glibcCheckWarn glibcCheck = "warn"
+	glibcCheckFail glibcCheck = "fail"
+	glibcCheckSkip glibcCheck = "skip"
+)
+
+func parseGLIBCCheck() (glibcCheck, error) {
+	value, ok := os.LookupEnv(GLIBCCheckEnvVar)
+	if !ok {
+		return glibcCheckWarn, nil
+	}
+
+	switch check := glibcCheck(strings.ToLower(strings.TrimSpace(value))); check {
+	case glibcCheckWarn, glibcCheckFail, glibcCheckSkip:
+		return check, nil
+	default:
+		return "", fmt.Errorf("failed to parse %s value %q: expected one of %q, %q or %q", GLIBCCheckEnvVar, value, glibcCheckWarn, glibcCheckFail, glibcCheckSkip)
+	}
+}
+
+// apply logs the outcome of a glibc compatibility check, and returns an error
+// instead of continuing when symbol versions are missing and the check fails
+// the build. The check reads the glibc of the build image, so a warning is
+// logged when the distribution of the run image, as given by the target, is
+// not the one of the build image.
+func (c glibcCheck) apply(logger scribe.Emitter, report GLIBCReport, target buildTarget) error {
+	if report.Libc == "" {
+		logger.Subprocess("WARNING: No glibc was found on the stack, skipping the glibc compatibility check")
+		logger.Break()
+		return nil
+	}
+
+	if target.Distro != "" && !sameDistro(report, target) {
+		buildImage := strings.TrimSpace(report.Distro + " " + report.DistroVersion)
+		if buildImage == "" {
+			buildImage = "an unknown distribution"
+		}
+
+		logger.Subprocess("WARNING: The glibc check used the build image (%s), but the run image is %s", buildImage, strings.TrimSpace(target.Distro+" "+target.DistroVersion))
+		logger.Action("Its result may not apply to the run image, which may provide another glibc version.")
+		logger.Break()
+	}
+
+	if len(report.Missing) == 0 {
+		return nil
+	}
+
+	if c == glibcCheckFail {
+		var message strings.Builder
+		fmt.Fprintf(&message, "vsdbg needs symbol versions that are newer than %s provided by %s:", report.Version, report.Libc)
+		for _, missing := range report.Missing {
+			fmt.Fprintf(&message, "\n  %s imports %s@%s from %s", missing.File, missing.Symbol, missing.Version, missing.Library)
+		}
+		fmt.Fprintf(&message, "\nuse a stack with a newer glibc, or set %s to %q or %q", GLIBCCheckEnvVar, glibcCheckWarn, glibcCheckSkip)
+
+		return errors.New(message.String())
+	}
+
+	logger.Subprocess("WARNING: vsdbg needs symbol versions that are newer than %s provided by %s", report.Version, report.Libc)
+	for _, missing := range report.Missing {
+		logger.Action("%s imports %s@%s from %s", missing.File, missing.Symbol, missing.Version, missing.Library)
+	}
+	logger.Action("The debugger may fail to start. Use a stack with a newer glibc, or set %s to %q to fail the build.", GLIBCCheckEnvVar, glibcCheckFail)
+	logger.Break()
+
+	return nil
+}
+
+// sameDistro reports whether the build image that the glibc report was made
+// on is the distribution of the target. A target without a distribution
+// version matches any version.
+func sameDistro(report GLIBCReport, target buildTarget) bool {
+	if !strings.EqualFold(report.Distro, target.Distro) {
+		return false
+	}
+
+	return target.DistroVersion == "" || report.DistroVersion == target.DistroVersion
+}
@@ -11,5 +11,8 @@ func TestUnitVSDBG(t *testing.T) {
 	suite := spec.New("vsdbg", spec.Report(report.Terminal{}))
 	suite("Detect", testDetect)
 	suite("Build", testBuild)
+	suite("BinaryVerifier", testBinaryVerifier)
+	suite("BuildpackYMLParser", testBuildpackYMLParser)
+	suite("ProjectFileParser", testProjectFileParser)
 	suite.Run(t)
 }
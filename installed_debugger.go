@@ -0,0 +1,92 @@
+package vsdbg
+
+import (
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// installedDebugger describes the debugger installed in the layer, so that
+// later buildpacks, IDE integrations and image audits can find it without
+// running the image.
+type installedDebugger struct {
+	Version  string
+	Arch     string
+	Checksum string
+	Source   string
+}
+
+// newInstalledDebugger returns the description of the given dependency
+// installed for the given target. The version is given in its upstream form,
+// w.x.y.z, rather than the semantic version form of buildpack.toml, so that it
+// matches the releases of the debugger. The source is the upstream URI of the
+// dependency, or the URI it was installed from if it has none. For an archive,
+// it is the variable or binding that configured the archive.
+func newInstalledDebugger(dependency postal.Dependency, target buildTarget) installedDebugger {
+	source := dependency.Source
+	if source == "" {
+		source = dependency.URI
+	}
+
+	version := dependency.Version
+	if v, ok := parseDebuggerVersion(version); ok {
+		version = v.upstreamString()
+	}
+
+	return installedDebugger{
+		Version:  version,
+		Arch:     target.Arch,
+		Checksum: dependency.Checksum,
+		Source:   source,
+	}
+}
+
+// configureEnvironment sets the variables that locate the debugger in the
+// given layer for later buildpacks and at launch.
+func (d installedDebugger) configureEnvironment(layer packit.Layer) {
+	layer.SharedEnv.Override(HomeEnvVar, layer.Path)
+	if d.Version != "" {
+		layer.SharedEnv.Override(VersionEnvVar, d.Version)
+	}
+}
+
+// labels returns the image labels that describe the debugger. Labels whose
+// value is unknown, such as the version of a debugger installed from an
+// archive, are left out.
+func (d installedDebugger) labels() map[string]string {
+	labels := map[string]string{}
+	for key, value := range map[string]string{
+		VersionLabel:  d.Version,
+		ArchLabel:     d.Arch,
+		ChecksumLabel: d.Checksum,
+		SourceLabel:   d.Source,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+
+	return labels
+}
+
+// launchMetadata returns the launch metadata that labels the image with the
+// debugger, and logs the labels. The image is only labeled when the debugger
+// is available at launch.
+func (d installedDebugger) launchMetadata(logger scribe.Emitter, launch bool) packit.LaunchMetadata {
+	if !launch {
+		return packit.LaunchMetadata{}
+	}
+
+	labels := d.labels()
+
+	formatted := scribe.FormattedMap{}
+	for key, value := range labels {
+		formatted[key] = value
+	}
+
+	logger.Process("Configuring image labels")
+	logger.Subprocess("%s", formatted)
+	logger.Break()
+
+	return packit.LaunchMetadata{Labels: labels}
+}
@@ -79,10 +79,14 @@ func testDefault(t *testing.T, context spec.G, it spec.S) {
 			))
 			Expect(logs).To(ContainLines(
 				"  Configuring build environment",
-				MatchRegexp(fmt.Sprintf(`    PATH -> "\$PATH:\/layers\/%s\/vsdbg"`, strings.ReplaceAll(buildpackInfo.Buildpack.ID, "/", "_"))),
+				MatchRegexp(fmt.Sprintf(`    PATH\s+-> "\$PATH:\/layers\/%s\/vsdbg"`, strings.ReplaceAll(buildpackInfo.Buildpack.ID, "/", "_"))),
+				MatchRegexp(fmt.Sprintf(`    VSDBG_HOME\s+-> "\/layers\/%s\/vsdbg"`, strings.ReplaceAll(buildpackInfo.Buildpack.ID, "/", "_"))),
+				MatchRegexp(`    VSDBG_VERSION\s+-> "\d+\.\d+\.\d+\.\d+"`),
 				"",
 				"  Configuring launch environment",
-				MatchRegexp(fmt.Sprintf(`    PATH -> "\$PATH:\/layers\/%s\/vsdbg"`, strings.ReplaceAll(buildpackInfo.Buildpack.ID, "/", "_"))),
+				MatchRegexp(fmt.Sprintf(`    PATH\s+-> "\$PATH:\/layers\/%s\/vsdbg"`, strings.ReplaceAll(buildpackInfo.Buildpack.ID, "/", "_"))),
+				MatchRegexp(fmt.Sprintf(`    VSDBG_HOME\s+-> "\/layers\/%s\/vsdbg"`, strings.ReplaceAll(buildpackInfo.Buildpack.ID, "/", "_"))),
+				MatchRegexp(`    VSDBG_VERSION\s+-> "\d+\.\d+\.\d+\.\d+"`),
 			))
 
 			container, err = docker.Container.Run.
@@ -0,0 +1,179 @@
+package vsdbg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// cacheValidation is how thoroughly a cached layer is checked against its
+// manifest before it is reused.
+type cacheValidation string
+
+const (
+	// cacheValidationPresence checks that every file in the manifest exists.
+	cacheValidationPresence cacheValidation = "presence"
+
+	// cacheValidationHash also checks the digest of every file.
+	cacheValidationHash cacheValidation = "hash"
+)
+
+func parseCacheValidation() (cacheValidation, error) {
+	value, ok := os.LookupEnv(CacheValidationEnvVar)
+	if !ok {
+		return cacheValidationHash, nil
+	}
+
+	switch validation := cacheValidation(strings.ToLower(strings.TrimSpace(value))); validation {
+	case cacheValidationPresence, cacheValidationHash:
+		return validation, nil
+	default:
+		return "", fmt.Errorf("failed to parse %s value %q: expected one of %q or %q", CacheValidationEnvVar, value, cacheValidationPresence, cacheValidationHash)
+	}
+}
+
+// layerManifest summarizes the regular files installed in a layer as their
+// number and a single digest of their paths, relative to the layer, and their
+// SHA256 digests. Only the summary is stored in layer metadata, as the
+// lifecycle copies the metadata of launch layers into an image label.
+type layerManifest struct {
+	Digest string
+	Files  int
+}
+
+// newLayerManifest returns the manifest of the files in the layer at the
+// given path.
+func newLayerManifest(path string) (layerManifest, error) {
+	manifest, err := scanLayer(path, true)
+	if err != nil {
+		return layerManifest{}, fmt.Errorf("failed to write the manifest of layer %s: %w", path, err)
+	}
+
+	return manifest, nil
+}
+
+// scanLayer counts the regular files in the layer at the given path and, if
+// digest is true, computes the digest of the tree of files. The tree digest
+// is the SHA256 digest of the sorted relative path and digest of each file.
+func scanLayer(path string, digest bool) (layerManifest, error) {
+	var entries []string
+	err := filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(path, filePath)
+		if err != nil {
+			return err
+		}
+
+		line := filepath.ToSlash(relativePath)
+		if digest {
+			fileHash, err := fileDigest(filePath)
+			if err != nil {
+				return err
+			}
+			line = fmt.Sprintf("%s\x00%s", line, fileHash)
+		}
+
+		entries = append(entries, line)
+		return nil
+	})
+	if err != nil {
+		return layerManifest{}, err
+	}
+
+	manifest := layerManifest{Files: len(entries)}
+	if digest {
+		slices.Sort(entries)
+
+		hash := sha256.New()
+		for _, entry := range entries {
+			fmt.Fprintln(hash, entry)
+		}
+		manifest.Digest = "sha256:" + hex.EncodeToString(hash.Sum(nil))
+	}
+
+	return manifest, nil
+}
+
+// parseLayerManifest returns the manifest stored in layer metadata, and
+// reports whether the metadata holds one.
+func parseLayerManifest(metadata interface{}) (layerManifest, bool) {
+	entries, ok := metadata.(map[string]interface{})
+	if !ok {
+		return layerManifest{}, false
+	}
+
+	var manifest layerManifest
+	manifest.Digest, ok = entries["digest"].(string)
+	if !ok || manifest.Digest == "" {
+		return layerManifest{}, false
+	}
+
+	// Integers are decoded from the layer TOML as int64.
+	switch files := entries["files"].(type) {
+	case int:
+		manifest.Files = files
+	case int64:
+		manifest.Files = int(files)
+	default:
+		return layerManifest{}, false
+	}
+
+	return manifest, true
+}
+
+// metadata returns the manifest in the form it is stored in layer metadata.
+func (m layerManifest) metadata() map[string]interface{} {
+	return map[string]interface{}{
+		"digest": m.Digest,
+		"files":  m.Files,
+	}
+}
+
+// verify returns an error if the layer at the given path does not match the
+// manifest, checked to the given depth.
+func (m layerManifest) verify(path string, validation cacheValidation) error {
+	actual, err := scanLayer(path, validation == cacheValidationHash)
+	if err != nil {
+		return err
+	}
+
+	if actual.Files != m.Files {
+		return fmt.Errorf("expected %d files in the layer, found %d", m.Files, actual.Files)
+	}
+
+	if validation == cacheValidationHash && actual.Digest != m.Digest {
+		return errors.New("the files of the layer have been modified")
+	}
+
+	return nil
+}
+
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,51 @@
+package vsdbg
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ProjectFileParser reads the Visual Studio Debugger version constraint from
+// the VsdbgVersion MSBuild property of a .NET project file.
+type ProjectFileParser struct{}
+
+func NewProjectFileParser() ProjectFileParser {
+	return ProjectFileParser{}
+}
+
+// ParseVersion returns the value of the first VsdbgVersion property declared
+// in the given project file. An empty version is returned if the file does not
+// exist or does not declare the property.
+func (p ProjectFileParser) ParseVersion(path string) (string, error) {
+	var project struct {
+		PropertyGroups []struct {
+			VsdbgVersion string `xml:"VsdbgVersion"`
+		} `xml:"PropertyGroup"`
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("failed to read project file: %w", err)
+	}
+	defer file.Close()
+
+	err = xml.NewDecoder(file).Decode(&project)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode project file: %w", err)
+	}
+
+	for _, group := range project.PropertyGroups {
+		if version := strings.TrimSpace(group.VsdbgVersion); version != "" {
+			return version, nil
+		}
+	}
+
+	return "", nil
+}
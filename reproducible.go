@@ -0,0 +1,104 @@
+package vsdbg
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// defaultSourceDate is the modification time of the files in the layer when
+// SOURCE_DATE_EPOCH is not set. It matches the time that the lifecycle gives
+// the files of the layers it exports.
+var defaultSourceDate = time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+func parseSourceDateEpoch() (time.Time, error) {
+	value, ok := os.LookupEnv(SourceDateEpochEnvVar)
+	if !ok || strings.TrimSpace(value) == "" {
+		return defaultSourceDate, nil
+	}
+
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, fmt.Errorf("failed to parse %s value %q: expected a non-negative number of seconds since the Unix epoch", SourceDateEpochEnvVar, value)
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// normalizeLayer gives the files in the layer at the given path the same
+// modification time, the owner of the build process, and permissions that
+// only depend on whether they are executable, so that installing the same
+// debugger always produces the same layer diff ID.
+func normalizeLayer(path string, sourceDate time.Time) error {
+	var paths []string
+	err := filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		paths = append(paths, filePath)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to normalize layer %s: %w", path, err)
+	}
+
+	uid, gid := os.Getuid(), os.Getgid()
+	times := []unix.Timeval{
+		unix.NsecToTimeval(sourceDate.UnixNano()),
+		unix.NsecToTimeval(sourceDate.UnixNano()),
+	}
+
+	// The files in a directory are normalized before the directory, so that
+	// changes to its files do not change its modification time afterwards.
+	for _, filePath := range slices.Backward(paths) {
+		info, err := os.Lstat(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to normalize layer %s: %w", path, err)
+		}
+
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok && (int(stat.Uid) != uid || int(stat.Gid) != gid) {
+			err = os.Lchown(filePath, uid, gid)
+			if err != nil {
+				return fmt.Errorf("failed to normalize layer %s: %w", path, err)
+			}
+		}
+
+		if mode, ok := normalizedMode(info.Mode()); ok && info.Mode().Perm() != mode {
+			err = os.Chmod(filePath, mode)
+			if err != nil {
+				return fmt.Errorf("failed to normalize layer %s: %w", path, err)
+			}
+		}
+
+		err = unix.Lutimes(filePath, times)
+		if err != nil {
+			return fmt.Errorf("failed to normalize layer %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// normalizedMode returns the permissions of a directory or regular file with
+// the given mode, and reports whether the file has permissions of its own.
+// Executable files and directories are readable and executable by everyone,
+// and other files are readable by everyone. Symbolic links are not changed.
+func normalizedMode(mode fs.FileMode) (fs.FileMode, bool) {
+	switch {
+	case mode.IsDir(), mode.IsRegular() && mode.Perm()&0111 != 0:
+		return 0755, true
+	case mode.IsRegular():
+		return 0644, true
+	default:
+		return 0, false
+	}
+}
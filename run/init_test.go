@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitRun(t *testing.T) {
+	suite := spec.New("run", spec.Report(report.Terminal{}))
+	suite("SBOMGenerator", testSBOMGenerator)
+	suite.Run(t)
+}
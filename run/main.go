@@ -7,26 +7,25 @@ import (
 	"github.com/paketo-buildpacks/packit/v2/cargo"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/postal"
-	"github.com/paketo-buildpacks/packit/v2/sbom"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 	"github.com/paketo-buildpacks/vsdbg"
 )
 
-type SBOMGenerator struct{}
-
-func (s SBOMGenerator) Generate(path string) (sbom.SBOM, error) {
-	return sbom.Generate(path)
-}
-
 func main() {
 
 	logger := scribe.NewEmitter(os.Stdout).WithLevel(os.Getenv("BP_LOG_LEVEL"))
 	dependencyManager := postal.NewService(cargo.NewTransport())
 
 	packit.Run(
-		vsdbg.Detect(),
+		vsdbg.Detect(
+			vsdbg.NewBuildpackYMLParser(),
+			vsdbg.NewProjectFileParser(),
+		),
 		vsdbg.Build(
 			dependencyManager,
+			servicebindings.NewResolver(),
+			vsdbg.NewBinaryVerifier(),
 			SBOMGenerator{},
 			logger,
 			chronos.DefaultClock))
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anchore/syft/syft/format/syftjson"
+	"github.com/anchore/syft/syft/pkg"
+	syftsbom "github.com/anchore/syft/syft/sbom"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+)
+
+type SBOMGenerator struct{}
+
+func (s SBOMGenerator) Generate(path string) (sbom.SBOM, error) {
+	return sbom.Generate(path)
+}
+
+func (s SBOMGenerator) GenerateFromDependency(dependency postal.Dependency, path string) (sbom.SBOM, error) {
+	return sbom.GenerateFromDependency(dependency, path)
+}
+
+// GenerateWithDependency scans the given path with sbom.Generate and adds the
+// package that sbom.GenerateFromDependency records for the dependency to the
+// packages that are found. The SBOMs are merged through their Syft JSON form,
+// as packit does not expose the packages of an SBOM.
+func (s SBOMGenerator) GenerateWithDependency(dependency postal.Dependency, path string) (sbom.SBOM, error) {
+	scan, err := sbom.Generate(path)
+	if err != nil {
+		return sbom.SBOM{}, err
+	}
+
+	fromDependency, err := sbom.GenerateFromDependency(dependency, path)
+	if err != nil {
+		return sbom.SBOM{}, err
+	}
+
+	merged, err := decodeSBOM(scan)
+	if err != nil {
+		return sbom.SBOM{}, err
+	}
+
+	dependencyBOM, err := decodeSBOM(fromDependency)
+	if err != nil {
+		return sbom.SBOM{}, err
+	}
+
+	if merged.Artifacts.Packages == nil {
+		merged.Artifacts.Packages = pkg.NewCollection()
+	}
+	for p := range dependencyBOM.Artifacts.Packages.Enumerate() {
+		merged.Artifacts.Packages.Add(p)
+	}
+
+	return sbom.NewSBOM(*merged), nil
+}
+
+func decodeSBOM(bom sbom.SBOM) (*syftsbom.SBOM, error) {
+	decoded, _, _, err := syftjson.NewFormatDecoder().Decode(sbom.NewFormattedReader(bom, sbom.SyftFormat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode SBOM: %w", err)
+	}
+
+	return decoded, nil
+}
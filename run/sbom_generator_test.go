@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testSBOMGenerator(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir  string
+		generator SBOMGenerator
+	)
+
+	it.Before(func() {
+		layerDir = t.TempDir()
+
+		Expect(os.WriteFile(filepath.Join(layerDir, "package.json"), []byte(`{
+  "name": "some-app",
+  "version": "1.2.3",
+  "devDependencies": {"some-dev-dependency": "4.5.6"}
+}`), 0600)).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(layerDir, "package-lock.json"), []byte(`{
+  "name": "some-app",
+  "version": "1.2.3",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "some-app", "version": "1.2.3", "devDependencies": {"some-dev-dependency": "4.5.6"}},
+    "node_modules/some-dev-dependency": {"version": "4.5.6", "dev": true}
+  }
+}`), 0600)).To(Succeed())
+	})
+
+	context("GenerateWithDependency", func() {
+		it("adds the dependency to the packages found by the scan", func() {
+			bom, err := generator.GenerateWithDependency(postal.Dependency{
+				ID:       "vsdbg",
+				Name:     "Visual Studio Debugger",
+				Version:  "18.7.10521+2",
+				CPE:      "cpe:2.3:a:microsoft:vsdbg:18.7.10521.2:*:*:*:*:*:*:*",
+				PURL:     "pkg:generic/vsdbg@18.7.10521.2?checksum=some-sha",
+				Licenses: []string{"LicenseRef-vsdbg-EULA"},
+			}, layerDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			content, err := io.ReadAll(sbom.NewFormattedReader(bom, sbom.SyftFormat))
+			Expect(err).NotTo(HaveOccurred())
+
+			var document struct {
+				Artifacts []struct {
+					Name     string `json:"name"`
+					Version  string `json:"version"`
+					PURL     string `json:"purl"`
+					Licenses []struct {
+						Value string `json:"value"`
+					} `json:"licenses"`
+				} `json:"artifacts"`
+				Source struct {
+					Type string `json:"type"`
+				} `json:"source"`
+			}
+			Expect(json.Unmarshal(content, &document)).To(Succeed())
+
+			Expect(document.Source.Type).To(Equal("directory"))
+
+			versions := map[string]string{}
+			for _, artifact := range document.Artifacts {
+				versions[artifact.Name] = artifact.Version
+
+				if artifact.Name == "Visual Studio Debugger" {
+					Expect(artifact.PURL).To(Equal("pkg:generic/vsdbg@18.7.10521.2?checksum=some-sha"))
+					Expect(artifact.Licenses).To(HaveLen(1))
+					Expect(artifact.Licenses[0].Value).To(Equal("LicenseRef-vsdbg-EULA"))
+				}
+			}
+
+			// The dev dependency is only found with the cataloger configuration
+			// of sbom.Generate.
+			Expect(versions).To(HaveKeyWithValue("Visual Studio Debugger", "18.7.10521+2"))
+			Expect(versions).To(HaveKeyWithValue("some-dev-dependency", "4.5.6"))
+		})
+
+		context("when the path does not exist", func() {
+			it("returns an error", func() {
+				_, err := generator.GenerateWithDependency(postal.Dependency{}, filepath.Join(layerDir, "missing"))
+				Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+		})
+	})
+}
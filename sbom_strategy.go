@@ -0,0 +1,80 @@
+package vsdbg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+)
+
+// sbomStrategy is how the SBOM of the layer is generated.
+type sbomStrategy string
+
+const (
+	// sbomStrategyScan scans the files installed in the layer.
+	sbomStrategyScan sbomStrategy = "scan"
+
+	// sbomStrategyDependency derives the SBOM from the metadata of the
+	// installed dependency, without scanning the layer.
+	sbomStrategyDependency sbomStrategy = "dependency"
+
+	// sbomStrategyBoth scans the layer and adds the installed dependency to
+	// the packages that are found.
+	sbomStrategyBoth sbomStrategy = "both"
+)
+
+func parseSBOMStrategy() (sbomStrategy, error) {
+	value, ok := os.LookupEnv(SBOMStrategyEnvVar)
+	if !ok {
+		return sbomStrategyBoth, nil
+	}
+
+	switch strategy := sbomStrategy(strings.ToLower(strings.TrimSpace(value))); strategy {
+	case sbomStrategyScan, sbomStrategyDependency, sbomStrategyBoth:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("failed to parse %s value %q: expected one of %q, %q or %q", SBOMStrategyEnvVar, value, sbomStrategyScan, sbomStrategyDependency, sbomStrategyBoth)
+	}
+}
+
+// generate returns the SBOM of the dependency installed in the layer at the
+// given path.
+func (s sbomStrategy) generate(generator SBOMGenerator, dependency postal.Dependency, path string) (sbom.SBOM, error) {
+	switch s {
+	case sbomStrategyDependency:
+		return generator.GenerateFromDependency(sbomDependency(dependency), path)
+	case sbomStrategyBoth:
+		return generator.GenerateWithDependency(sbomDependency(dependency), path)
+	default:
+		return generator.Generate(path)
+	}
+}
+
+// sbomDependency returns the dependency as it is recorded in the SBOM. A
+// debugger installed from a local archive has no package URL or licenses in
+// buildpack.toml, so a package URL that records the checksum of the archive
+// and the license reference of the EULA are added.
+func sbomDependency(dependency postal.Dependency) postal.Dependency {
+	if len(dependency.Licenses) == 0 {
+		dependency.Licenses = []string{LicenseRef}
+	}
+
+	if dependency.PURL != "" {
+		return dependency
+	}
+
+	purl := fmt.Sprintf("pkg:generic/%s", dependency.ID)
+	if dependency.Version != "" {
+		purl = fmt.Sprintf("%s@%s", purl, dependency.Version)
+	}
+
+	if hash := cargo.Checksum(dependency.Checksum).Hash(); hash != "" {
+		purl = fmt.Sprintf("%s?checksum=%s", purl, hash)
+	}
+
+	dependency.PURL = purl
+	return dependency
+}
@@ -0,0 +1,99 @@
+package vsdbg
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+)
+
+// buildTarget is the platform that the debugger is installed for, as
+// described by the CNB_TARGET_* variables of the Buildpack API. It may differ
+// from the platform the buildpack is running on, for example when an image is
+// built for another architecture through emulation.
+type buildTarget struct {
+	OS            string
+	Arch          string
+	Variant       string
+	Distro        string
+	DistroVersion string
+}
+
+// newBuildTarget returns the target described by the given target info and
+// distribution. When the platform does not provide the target os or
+// architecture, those of the running buildpack are used, as they are by
+// postal.
+func newBuildTarget(info packit.TargetInfo, distro packit.TargetDistro) buildTarget {
+	target := buildTarget{
+		OS:            info.OS,
+		Arch:          info.Arch,
+		Variant:       info.Variant,
+		Distro:        distro.Name,
+		DistroVersion: distro.Version,
+	}
+
+	if target.OS == "" {
+		target.OS = runtime.GOOS
+	}
+
+	if target.Arch == "" {
+		target.Arch = runtime.GOARCH
+	}
+
+	return target
+}
+
+// targetFromEnvironment returns the target described by the CNB_TARGET_*
+// variables. Unlike the build context, the detect context does not include
+// the target, so detection reads the variables itself.
+func targetFromEnvironment() buildTarget {
+	return newBuildTarget(packit.TargetInfo{
+		OS:      os.Getenv("CNB_TARGET_OS"),
+		Arch:    os.Getenv("CNB_TARGET_ARCH"),
+		Variant: os.Getenv("CNB_TARGET_VARIANT"),
+	}, packit.TargetDistro{
+		Name:    os.Getenv("CNB_TARGET_DISTRO_NAME"),
+		Version: os.Getenv("CNB_TARGET_DISTRO_VERSION"),
+	})
+}
+
+func (t buildTarget) String() string {
+	platform := fmt.Sprintf("%s/%s", t.OS, t.Arch)
+	if t.Variant != "" {
+		platform = fmt.Sprintf("%s/%s", platform, t.Variant)
+	}
+
+	if t.Distro == "" {
+		return platform
+	}
+
+	return fmt.Sprintf("%s (%s)", platform, strings.TrimSpace(t.Distro+" "+t.DistroVersion))
+}
+
+// isMusl reports whether the target distribution uses musl libc.
+func (t buildTarget) isMusl() bool {
+	return slices.ContainsFunc(muslDistros, func(distro string) bool {
+		return strings.EqualFold(t.Distro, distro)
+	})
+}
+
+// supports reports whether the dependency can be installed on the target.
+// Dependencies that do not declare an os and architecture, or any distros,
+// are not restricted by them.
+func (t buildTarget) supports(dependency postal.Dependency) bool {
+	if (dependency.OS != "" || dependency.Arch != "") && (dependency.OS != t.OS || dependency.Arch != t.Arch) {
+		return false
+	}
+
+	if len(dependency.Distros) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(dependency.Distros, func(distro postal.Distro) bool {
+		return strings.EqualFold(distro.Name, t.Distro) && (distro.Version == "" || distro.Version == t.DistroVersion)
+	})
+}
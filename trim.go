@@ -0,0 +1,197 @@
+package vsdbg
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// trimCategory is a kind of file in the debugger payload that is not needed
+// to debug an application and may be removed from the layer.
+type trimCategory string
+
+const (
+	// trimLocales removes the directories of localized resources.
+	trimLocales trimCategory = "locales"
+
+	// trimSymbols removes the symbol files of the debugger itself.
+	trimSymbols trimCategory = "symbols"
+)
+
+// cultureName matches the names of the directories that .NET satellite
+// assemblies are installed in, such as de, pt-BR or zh-Hans.
+var cultureName = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// symbolFileExtensions are the extensions of the symbol files in the payload.
+var symbolFileExtensions = []string{".dbg", ".debug", ".pdb"}
+
+// trimProfile is the set of file categories that are removed from the layer
+// after the debugger is installed.
+type trimProfile struct {
+	categories []trimCategory
+
+	// cultures are the cultures whose resources are kept when localized
+	// resources are removed.
+	cultures []string
+}
+
+func parseTrimProfile() (trimProfile, error) {
+	var profile trimProfile
+	for _, field := range splitList(os.Getenv(TrimEnvVar)) {
+		category := trimCategory(strings.ToLower(field))
+		switch category {
+		case trimLocales, trimSymbols:
+			if !slices.Contains(profile.categories, category) {
+				profile.categories = append(profile.categories, category)
+			}
+		default:
+			return trimProfile{}, fmt.Errorf("failed to parse %s value %q: unknown category %q: expected %q or %q", TrimEnvVar, os.Getenv(TrimEnvVar), field, trimLocales, trimSymbols)
+		}
+	}
+
+	for _, culture := range splitList(os.Getenv(TrimCulturesEnvVar)) {
+		if !cultureName.MatchString(culture) {
+			return trimProfile{}, fmt.Errorf("failed to parse %s value %q: %q is not a culture name", TrimCulturesEnvVar, os.Getenv(TrimCulturesEnvVar), culture)
+		}
+
+		culture = strings.ToLower(culture)
+		if !slices.Contains(profile.cultures, culture) {
+			profile.cultures = append(profile.cultures, culture)
+		}
+	}
+
+	// An allow-list of cultures implies that the other cultures are removed.
+	if len(profile.cultures) > 0 && !slices.Contains(profile.categories, trimLocales) {
+		profile.categories = append(profile.categories, trimLocales)
+	}
+
+	slices.Sort(profile.categories)
+	slices.Sort(profile.cultures)
+
+	return profile, nil
+}
+
+func splitList(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// String returns the profile in the form it is stored in layer metadata, such
+// as "locales,symbols" or "locales:de|ja", or an empty string if nothing is
+// removed.
+func (p trimProfile) String() string {
+	categories := make([]string, len(p.categories))
+	for i, category := range p.categories {
+		categories[i] = string(category)
+		if category == trimLocales && len(p.cultures) > 0 {
+			categories[i] = fmt.Sprintf("%s:%s", category, strings.Join(p.cultures, "|"))
+		}
+	}
+
+	return strings.Join(categories, ",")
+}
+
+// trimResult is what was removed from a layer.
+type trimResult struct {
+	Files int
+	Bytes int64
+}
+
+// trim removes the files matching the profile from the layer at the given
+// path.
+func (p trimProfile) trim(path string) (trimResult, error) {
+	var result trimResult
+	if len(p.categories) == 0 {
+		return result, nil
+	}
+
+	var removals []string
+	err := filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if filePath == path {
+			return nil
+		}
+
+		if entry.IsDir() {
+			if slices.Contains(p.categories, trimLocales) && p.isRemovedCulture(filePath, entry.Name()) {
+				removals = append(removals, filePath)
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if slices.Contains(p.categories, trimSymbols) && entry.Type().IsRegular() && slices.Contains(symbolFileExtensions, strings.ToLower(filepath.Ext(entry.Name()))) {
+			removals = append(removals, filePath)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return trimResult{}, fmt.Errorf("failed to trim layer %s: %w", path, err)
+	}
+
+	for _, removal := range removals {
+		err = filepath.WalkDir(removal, func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if entry.Type().IsRegular() {
+				info, err := entry.Info()
+				if err != nil {
+					return err
+				}
+
+				result.Files++
+				result.Bytes += info.Size()
+			}
+
+			return nil
+		})
+		if err != nil {
+			return trimResult{}, fmt.Errorf("failed to trim layer %s: %w", path, err)
+		}
+
+		err = os.RemoveAll(removal)
+		if err != nil {
+			return trimResult{}, fmt.Errorf("failed to trim layer %s: %w", path, err)
+		}
+	}
+
+	return result, nil
+}
+
+// isRemovedCulture reports whether the directory at the given path holds the
+// localized resources of a culture that is not kept. Only directories that
+// are named after a culture and contain nothing but satellite assemblies are
+// treated as localized resources, so that other directories of the payload
+// are never removed.
+func (p trimProfile) isRemovedCulture(path, name string) bool {
+	if !cultureName.MatchString(name) || slices.Contains(p.cultures, strings.ToLower(name)) {
+		return false
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil || len(entries) == 0 {
+		return false
+	}
+
+	return !slices.ContainsFunc(entries, func(entry fs.DirEntry) bool {
+		return !entry.Type().IsRegular() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".resources.dll")
+	})
+}
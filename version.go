@@ -0,0 +1,86 @@
+package vsdbg
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// debuggerVersionPattern matches both the upstream form of a debugger version,
+// w.x.y.z, and the semantic version form recorded in buildpack.toml, w.x.y+z.
+var debuggerVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:[.+](\d+))?$`)
+
+// debuggerVersion is a Visual Studio Debugger version. Upstream versions have
+// four numeric components, which buildpack.toml records as the semantic
+// version w.x.y+z. Semantic versions ignore build metadata when ordering, so
+// the fourth component is compared explicitly here.
+type debuggerVersion struct {
+	major, minor, patch, revision uint64
+
+	// hasRevision is true when the fourth component was given.
+	hasRevision bool
+}
+
+func parseDebuggerVersion(version string) (debuggerVersion, bool) {
+	matches := debuggerVersionPattern.FindStringSubmatch(version)
+	if matches == nil {
+		return debuggerVersion{}, false
+	}
+
+	var components [4]uint64
+	for i, match := range matches[1:] {
+		if match == "" {
+			continue
+		}
+
+		component, err := strconv.ParseUint(match, 10, 64)
+		if err != nil {
+			return debuggerVersion{}, false
+		}
+		components[i] = component
+	}
+
+	return debuggerVersion{
+		major:       components[0],
+		minor:       components[1],
+		patch:       components[2],
+		revision:    components[3],
+		hasRevision: matches[4] != "",
+	}, true
+}
+
+// compare orders versions by all four of their components.
+func (v debuggerVersion) compare(other debuggerVersion) int {
+	return cmp.Or(
+		cmp.Compare(v.major, other.major),
+		cmp.Compare(v.minor, other.minor),
+		cmp.Compare(v.patch, other.patch),
+		cmp.Compare(v.revision, other.revision),
+	)
+}
+
+// sameRelease reports whether the versions differ only in their fourth
+// component.
+func (v debuggerVersion) sameRelease(other debuggerVersion) bool {
+	return v.major == other.major && v.minor == other.minor && v.patch == other.patch
+}
+
+// String returns the semantic version form of the version, w.x.y+z.
+func (v debuggerVersion) String() string {
+	if !v.hasRevision {
+		return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+	}
+
+	return fmt.Sprintf("%d.%d.%d+%d", v.major, v.minor, v.patch, v.revision)
+}
+
+// upstreamString returns the upstream form of the version, w.x.y.z, which is
+// the form used by GetVsDbg.sh, the CPE and the package URL of the debugger.
+func (v debuggerVersion) upstreamString() string {
+	if !v.hasRevision {
+		return fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+	}
+
+	return fmt.Sprintf("%d.%d.%d.%d", v.major, v.minor, v.patch, v.revision)
+}